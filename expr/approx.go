@@ -0,0 +1,14 @@
+package expr
+
+import "math"
+
+// ApproxEqual reports whether a and b differ by at most tol. Equal infinities are
+// approximately equal for any tolerance, different ones never are; NaN is never
+// approximately equal to anything.
+func ApproxEqual(a, b, tol float64) bool {
+	if a == b {
+		return true
+	}
+	d := math.Abs(a - b)
+	return !math.IsInf(d, 0) && d <= tol
+}
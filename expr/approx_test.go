@@ -0,0 +1,26 @@
+package expr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestApproxEqual(t *testing.T) {
+	tests := []struct {
+		a, b, tol float64
+		want      bool
+	}{
+		{0.30000000000000004, 0.3, 1e-9, true},
+		{0.30000000000000004, 0.3, 0, false},
+		{1, 1.5, 0.5, true},
+		{1, 1.5, 0.4, false},
+		{math.Inf(1), math.Inf(1), 0, true},
+		{math.Inf(1), math.Inf(-1), math.Inf(1), false},
+		{math.NaN(), math.NaN(), math.Inf(1), false},
+	}
+	for _, test := range tests {
+		if got := ApproxEqual(test.a, test.b, test.tol); got != test.want {
+			t.Errorf("ApproxEqual(%v, %v, %v) = %v, want %v", test.a, test.b, test.tol, got, test.want)
+		}
+	}
+}
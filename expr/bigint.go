@@ -0,0 +1,102 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// EvalBigInt evaluates the expression in unbounded integer arithmetic.
+// All numbers must be integers; a division must be exact or it is an error.
+// Since numbers are parsed as floats, literals beyond 2^53 may already have lost precision,
+// unless they were parsed with WithSourceLiterals.
+func EvalBigInt(e Expr) (*big.Int, error) {
+	return evalBigInt(e, false)
+}
+
+// EvalBigIntFloor is like EvalBigInt, but divisions round towards negative infinity
+// instead of failing when they are not exact.
+func EvalBigIntFloor(e Expr) (*big.Int, error) {
+	return evalBigInt(e, true)
+}
+
+func evalBigInt(e Expr, floor bool) (*big.Int, error) {
+	switch e := e.(type) {
+	case num:
+		f := float64(e)
+		if math.IsInf(f, 0) || math.IsNaN(f) || f != math.Trunc(f) {
+			return nil, fmt.Errorf("number %v is not an integer", e)
+		}
+		i, _ := big.NewFloat(f).Int(nil)
+		return i, nil
+
+	case literal:
+		if i, ok := new(big.Int).SetString(e.text, 10); ok {
+			return i, nil
+		}
+		return evalBigInt(e.num, floor)
+
+	case unary:
+		x, err := evalBigInt(e.x, floor)
+		if err != nil {
+			return nil, err
+		}
+		switch e.op {
+		case '+':
+			return x, nil
+		case '-':
+			return x.Neg(x), nil
+		}
+		return nil, fmt.Errorf("unsupported unary operator: %q", e.op)
+
+	case binary:
+		x, err := evalBigInt(e.x, floor)
+		if err != nil {
+			return nil, err
+		}
+		y, err := evalBigInt(e.y, floor)
+		if err != nil {
+			return nil, err
+		}
+		switch e.op {
+		case '+':
+			return x.Add(x, y), nil
+		case '-':
+			return x.Sub(x, y), nil
+		case '*':
+			return x.Mul(x, y), nil
+		case '/':
+			if y.Sign() == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			// Div and Mod implement Euclidean division; for floor division
+			// the quotient has to go one down when the divisor is negative.
+			q, m := new(big.Int).DivMod(x, y, new(big.Int))
+			if m.Sign() != 0 {
+				if !floor {
+					return nil, fmt.Errorf("division %v / %v is not exact", x, y)
+				}
+				if y.Sign() < 0 {
+					q.Sub(q, big.NewInt(1))
+				}
+			}
+			return q, nil
+		case '%':
+			if y.Sign() == 0 {
+				return nil, fmt.Errorf("modulo by zero")
+			}
+			return x.Rem(x, y), nil // truncated like math.Mod, with the sign of x
+		case '^':
+			n, err := exactExponent(new(big.Rat).SetInt(y), x.BitLen())
+			if err != nil {
+				return nil, err
+			}
+			if n < 0 {
+				return nil, fmt.Errorf("negative exponent %d", n)
+			}
+			return x.Exp(x, big.NewInt(n), nil), nil
+		}
+		return nil, fmt.Errorf("unsupported binary operator: %q", e.op)
+	}
+	return nil, fmt.Errorf("cannot evaluate %v as an integer", e)
+}
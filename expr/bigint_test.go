@@ -0,0 +1,79 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvalBigInt(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// 2^50 * 2^50 + 1 = 2^100 + 1, the trailing 1 is lost in float64
+		{"1125899906842624 * 1125899906842624 + 1", "1267650600228229401496703205377"},
+		{"-(3 - 10) * 6 / 21", "2"},
+		{"12 / -4", "-3"},
+		{"2 ^ 100 + 1", "1267650600228229401496703205377"},
+		{"-7 % 3", "-1"},
+		{"10 ^ 16384 / 10 ^ 16383", "10"},
+		{"(-1) ^ 1000000000001", "-1"}, // powers of 1 and -1 do not grow
+	}
+	for _, test := range tests {
+		got, err := EvalBigInt(mustParse(t, test.input))
+		if err != nil {
+			t.Fatalf("EvalBigInt(%q) failed: %v", test.input, err)
+		}
+		if got.String() != test.want {
+			t.Errorf("EvalBigInt(%q) = %s, want %s", test.input, got, test.want)
+		}
+	}
+}
+
+func TestEvalBigIntFloor(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"7 / 2", 3},
+		{"-7 / 2", -4},
+		{"7 / -2", -4},
+		{"-7 / -2", 3},
+	}
+	for _, test := range tests {
+		e := mustParse(t, test.input)
+		if _, err := EvalBigInt(e); err == nil {
+			t.Errorf("EvalBigInt(%q) succeeded, want inexact division error", test.input)
+		}
+		got, err := EvalBigIntFloor(e)
+		if err != nil {
+			t.Fatalf("EvalBigIntFloor(%q) failed: %v", test.input, err)
+		}
+		if got.Int64() != test.want {
+			t.Errorf("EvalBigIntFloor(%q) = %s, want %d", test.input, got, test.want)
+		}
+	}
+}
+
+func TestEvalBigIntErrors(t *testing.T) {
+	for _, input := range []string{"1.5 + 1", "1 / 0", "1 / (2 - 2)", "2 ^ -1", "2 ^ 100000", "1 % 0", "(2 ^ 16384) ^ 16384"} {
+		if _, err := EvalBigInt(mustParse(t, input)); err == nil {
+			t.Errorf("EvalBigInt(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestEvalBigIntSourceLiterals(t *testing.T) {
+	e, err := Parse(strings.NewReader("9007199254740993 - 9007199254740992"), WithSourceLiterals())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// as floats both literals are 2^53 and the difference would be 0
+	got, err := EvalBigInt(e)
+	if err != nil {
+		t.Fatalf("EvalBigInt failed: %v", err)
+	}
+	if got.Int64() != 1 {
+		t.Errorf("EvalBigInt = %s, want 1", got)
+	}
+}
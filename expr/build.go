@@ -0,0 +1,106 @@
+package expr
+
+import "fmt"
+
+// Num returns a number node.
+func Num(f float64) Expr {
+	return num(f)
+}
+
+// IsBinaryOperator reports whether op is a binary operator, accepted by Binary.
+func IsBinaryOperator(op rune) bool {
+	return priority(op) > 0
+}
+
+// Unary returns the unary operation op applied to x. The operator must be a sign or a
+// registered prefix operator, and x must not be nil; otherwise an error is returned,
+// instead of a tree that only fails when it is evaluated.
+func Unary(op rune, x Expr) (Expr, error) {
+	if !isPrefix(op) {
+		return nil, fmt.Errorf("unknown unary operator %q", op)
+	}
+	if x == nil {
+		return nil, fmt.Errorf("missing operand of unary %q", op)
+	}
+	return unary{op, x}, nil
+}
+
+// Binary returns the binary operation op with the operands x and y. The operator must be
+// one of '+', '-', '*', '/', '%' and '^', and no operand may be nil; otherwise an error is returned.
+func Binary(op rune, x, y Expr) (Expr, error) {
+	if !IsBinaryOperator(op) {
+		return nil, fmt.Errorf("unknown binary operator %q", op)
+	}
+	if x == nil || y == nil {
+		return nil, fmt.Errorf("missing operand of binary %q", op)
+	}
+	return binary{op, x, y}, nil
+}
+
+// Var returns a variable node, to be bound by EvalEnv. The name must be an identifier
+// that is neither a constant like pi nor a function, as Parse would not read it back as
+// a variable.
+func Var(name string) (Expr, error) {
+	if !isIdentifier(name) {
+		return nil, fmt.Errorf("invalid variable name %q", name)
+	}
+	if _, ok := constants[name]; ok {
+		return nil, fmt.Errorf("%s is a constant, not a variable", name)
+	}
+	if _, ok := functions[name]; ok {
+		return nil, fmt.Errorf("%s is a function, not a variable", name)
+	}
+	return variable(name), nil
+}
+
+// Call returns the call of the function name with the given arguments. The function
+// must be built in or registered with RegisterFunction, it must take that many
+// arguments, and no argument may be nil; otherwise an error is returned.
+func Call(name string, args ...Expr) (Expr, error) {
+	if err := checkArity(name, len(args)); err != nil {
+		return nil, err
+	}
+	for i, a := range args {
+		if a == nil {
+			return nil, fmt.Errorf("missing argument %d of %s", i+1, name)
+		}
+	}
+	return call{name, append([]Expr(nil), args...)}, nil
+}
+
+// MustUnary is like Unary but panics on misuse. It is meant for trees built by the program
+// itself, where an invalid operator is a bug.
+func MustUnary(op rune, x Expr) Expr {
+	e, err := Unary(op, x)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// MustBinary is like Binary but panics on misuse.
+func MustBinary(op rune, x, y Expr) Expr {
+	e, err := Binary(op, x, y)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// MustVar is like Var but panics on misuse.
+func MustVar(name string) Expr {
+	e, err := Var(name)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// MustCall is like Call but panics on misuse.
+func MustCall(name string, args ...Expr) Expr {
+	e, err := Call(name, args...)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
@@ -0,0 +1,63 @@
+package expr
+
+import "testing"
+
+func TestBuilders(t *testing.T) {
+	registerSqrt(t)
+	e := MustBinary('*', MustUnary('-', Num(2)), MustBinary('+', Num(1), Num(0.5)))
+	if got, err := e.Eval(); err != nil || got != -3 {
+		t.Errorf("Eval(%v) = %v, %v, want -3", e, got, err)
+	}
+	if Canonical(e) != "-2 * (1 + 0.5)" {
+		t.Errorf("built %q, want -2 * (1 + 0.5)", Canonical(e))
+	}
+
+	for _, op := range []rune{'&', '$', '(', 'x'} {
+		if _, err := Binary(op, Num(1), Num(2)); err == nil {
+			t.Errorf("Binary(%q) succeeded, want error", op)
+		}
+	}
+	for _, op := range []rune{'*', '/', '!'} {
+		if _, err := Unary(op, Num(1)); err == nil {
+			t.Errorf("Unary(%q) succeeded, want error", op)
+		}
+	}
+	if _, err := Binary('+', Num(1), nil); err == nil {
+		t.Error("Binary with a nil operand succeeded, want error")
+	}
+	if _, err := Unary('√', Num(4)); err != nil {
+		t.Errorf("Unary with a registered prefix failed: %v", err)
+	}
+
+	e = MustBinary('*', MustCall("max", Num(1), MustVar("x")), Num(2))
+	if got, err := EvalEnv(e, map[string]float64{"x": 3}); err != nil || got != 6 {
+		t.Errorf("EvalEnv(%v) = %v, %v, want 6", e, got, err)
+	}
+	if Canonical(e) != "max(1, x) * 2" {
+		t.Errorf("built %q, want max(1, x) * 2", Canonical(e))
+	}
+	for _, name := range []string{"", "2x", "a-b", "pi", "sqrt"} {
+		if _, err := Var(name); err == nil {
+			t.Errorf("Var(%q) succeeded, want error", name)
+		}
+	}
+	if _, err := Call("nosuch", Num(1)); err == nil {
+		t.Error("Call of an unknown function succeeded, want error")
+	}
+	if _, err := Call("sqrt", Num(1), Num(2)); err == nil {
+		t.Error("Call with too many arguments succeeded, want error")
+	}
+	if _, err := Call("max"); err == nil {
+		t.Error("Call of max without arguments succeeded, want error")
+	}
+	if _, err := Call("max", Num(1), nil); err == nil {
+		t.Error("Call with a nil argument succeeded, want error")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("MustBinary('&') did not panic")
+		}
+	}()
+	MustBinary('&', Num(2), Num(3))
+}
@@ -0,0 +1,137 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// A function can be called by name in an expression, like sqrt(2).
+type function struct {
+	arity int // number of arguments, or variadic for one or more
+	fn    func(args []float64) (float64, error)
+}
+
+// variadic is the arity of functions taking any number of arguments, but at least one.
+const variadic = -1
+
+// math1 wraps a function of one argument from the math package.
+func math1(f func(float64) float64) function {
+	return function{1, func(args []float64) (float64, error) { return f(args[0]), nil }}
+}
+
+// functions holds the functions the parser accepts in calls, by name.
+var functions = map[string]function{
+	"sqrt":  math1(math.Sqrt),
+	"cbrt":  math1(math.Cbrt),
+	"exp":   math1(math.Exp),
+	"log":   math1(math.Log),
+	"log2":  math1(math.Log2),
+	"log10": math1(math.Log10),
+	"sin":   math1(math.Sin),
+	"cos":   math1(math.Cos),
+	"tan":   math1(math.Tan),
+	"asin":  math1(math.Asin),
+	"acos":  math1(math.Acos),
+	"atan":  math1(math.Atan),
+	"abs":   math1(math.Abs),
+	"floor": math1(math.Floor),
+	"ceil":  math1(math.Ceil),
+	"round": math1(math.Round), // half away from zero: round(2.5) is 3
+	"trunc": math1(math.Trunc),
+	"atan2": {2, func(args []float64) (float64, error) { return math.Atan2(args[0], args[1]), nil }},
+	"hypot": {2, func(args []float64) (float64, error) { return math.Hypot(args[0], args[1]), nil }},
+	"pow":   {2, func(args []float64) (float64, error) { return math.Pow(args[0], args[1]), nil }},
+
+	// banker's rounding, half to even: roundeven(2.5) is 2, roundeven(3.5) is 4
+	"roundeven": math1(math.RoundToEven),
+	"min": {variadic, func(args []float64) (float64, error) {
+		m := args[0]
+		for _, x := range args[1:] {
+			m = math.Min(m, x)
+		}
+		return m, nil
+	}},
+	"max": {variadic, func(args []float64) (float64, error) {
+		m := args[0]
+		for _, x := range args[1:] {
+			m = math.Max(m, x)
+		}
+		return m, nil
+	}},
+}
+
+// RegisterFunction registers a function that expressions can call by name, next to the
+// built-in ones like sqrt, log, abs and min. It takes arity arguments, or one or more if
+// arity is negative; the parser rejects calls with a different number of arguments.
+// Like RegisterPrefix, it is meant to be called during initialisation. It panics if the
+// name is already in use or is not an identifier.
+func RegisterFunction(name string, arity int, fn func(args []float64) (float64, error)) {
+	if _, ok := functions[name]; ok || !isIdentifier(name) {
+		panic(fmt.Sprintf("RegisterFunction: %q is already in use or not a name", name))
+	}
+	if fn == nil {
+		panic("RegisterFunction: nil function")
+	}
+	functions[name] = function{max(arity, variadic), fn}
+}
+
+// checkArity returns an error if the function name cannot be called with n arguments.
+func checkArity(name string, n int) error {
+	f, ok := functions[name]
+	switch {
+	case !ok:
+		return fmt.Errorf("unknown function %s", name)
+	case f.arity == variadic && n == 0:
+		return fmt.Errorf("%s takes at least one argument", name)
+	case f.arity != variadic && f.arity != n:
+		return fmt.Errorf("%s takes %d arguments, got %d", name, f.arity, n)
+	}
+	return nil
+}
+
+// A call is a function applied to its arguments
+type call struct {
+	name string // a key of functions
+	args []Expr
+}
+
+func (c call) String() string {
+	args := make([]string, len(c.args))
+	for i, a := range c.args {
+		args[i] = a.String()
+	}
+	return fmt.Sprintf("%s(%s)", c.name, strings.Join(args, ", "))
+}
+
+func (c call) Eval() (float64, error) {
+	if err := checkArity(c.name, len(c.args)); err != nil {
+		return 0, err
+	}
+	args := make([]float64, len(c.args))
+	for i, a := range c.args {
+		x, err := a.Eval()
+		if err != nil {
+			return 0, fmt.Errorf("evaluation of argument %d of %s failed: %s", i+1, c.name, err)
+		}
+		args[i] = x
+	}
+	return functions[c.name].fn(args)
+}
+
+func (c call) Len() int {
+	n := 1
+	for _, a := range c.args {
+		n += a.Len()
+	}
+	return n
+}
+
+// Cost counts a call like the most expensive operator.
+func (c call) Cost() int {
+	n := callCost
+	for _, a := range c.args {
+		n += a.Cost()
+	}
+	return n
+}
@@ -0,0 +1,163 @@
+package expr
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// registerClamp registers the function clamp(x, lo, hi) for the duration of the test.
+func registerClamp(t *testing.T) {
+	t.Helper()
+	t.Cleanup(SnapshotRegistry().Restore)
+	RegisterFunction("clamp", 3, func(args []float64) (float64, error) {
+		return math.Min(math.Max(args[0], args[1]), args[2]), nil
+	})
+}
+
+func TestParseCall(t *testing.T) {
+	registerClamp(t)
+	tests := []struct {
+		input     string
+		want      float64
+		canonical string
+	}{
+		{"sqrt(16)", 4, "sqrt(16)"},
+		{"abs(-3)", 3, "abs(-3)"},
+		{"min(3, 1, 2)", 1, "min(3, 1, 2)"},
+		{"max(1,2*3)", 6, "max(1, 2 * 3)"},
+		{"log(1) + exp(0)", 1, "log(1) + exp(0)"},
+		{"2 * sqrt(9) + 1", 7, "2 * sqrt(9) + 1"},
+		{"-abs(-2) ^ 2", -4, "-(abs(-2) ^ 2)"},
+		{"pow(2, sqrt(min(9, 16)))", 8, "pow(2, sqrt(min(9, 16)))"},
+		{"clamp(5, 0, 1)", 1, "clamp(5, 0, 1)"},
+		// application with '@'
+		{"sqrt @ 9", 3, "sqrt(9)"},
+		{"abs @ -5", 5, "abs(-5)"},
+		{"abs @ -5 * 2", 10, "abs(-5) * 2"},
+		{"sqrt @ abs @ -16", 4, "sqrt(abs(-16))"},
+		{"sqrt @ (3 + 13) + 1", 5, "sqrt(3 + 13) + 1"},
+		{"-sqrt @ 2 ^ 2", -2, "-sqrt(2 ^ 2)"},
+		{"max @ 7", 7, "max(7)"},
+	}
+	for _, test := range tests {
+		e := mustParse(t, test.input)
+		if got, err := e.Eval(); err != nil || got != test.want {
+			t.Errorf("Eval(%q) = %v, %v, want %v", test.input, got, err, test.want)
+		}
+		if got := Canonical(e); got != test.canonical {
+			t.Errorf("Canonical(%q) = %q, want %q", test.input, got, test.canonical)
+		}
+		ev, err := EvalParse(strings.NewReader(test.input))
+		if err != nil {
+			t.Fatalf("EvalParse(%q) failed: %v", test.input, err)
+		}
+		if got, _ := ev.Eval(); got != test.want {
+			t.Errorf("EvalParse(%q) = %v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestRoundingHalfway(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		// round rounds half away from zero, like math.Round
+		{"round(2.5)", 3},
+		{"round(3.5)", 4},
+		{"round(-2.5)", -3},
+		// roundeven rounds half to even, like math.RoundToEven
+		{"roundeven(2.5)", 2},
+		{"roundeven(3.5)", 4},
+		{"roundeven(-2.5)", -2},
+		{"roundeven(2.51)", 3},
+	}
+	for _, test := range tests {
+		if got, err := mustParse(t, test.input).Eval(); err != nil || got != test.want {
+			t.Errorf("Eval(%q) = %v, %v, want %v", test.input, got, err, test.want)
+		}
+	}
+}
+
+func TestParseCallErrors(t *testing.T) {
+	registerClamp(t)
+	for _, input := range []string{
+		"foo(1)",      // unknown function
+		"sqrt",        // no arguments
+		"sqrt 2",      // no parentheses
+		"sqrt(1, 2)",  // too many arguments
+		"clamp(1, 2)", // too few arguments
+		"min()",       // variadic, but needs one argument
+		"sqrt(1",      // unclosed
+		"min(1 2)",    // missing separator
+		"sqrt @",      // nothing to apply to
+		"pow @ 2",     // applied to one argument only
+		"2 @ 3",       // not a function
+	} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", input)
+		}
+		if _, err := EvalParse(strings.NewReader(input)); err == nil {
+			t.Errorf("EvalParse(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestWithAllowedFunctions(t *testing.T) {
+	allow := WithAllowedFunctions("sqrt", "abs")
+	for _, input := range []string{"sqrt(16) + abs(-2)", "1 + 2"} {
+		if _, err := Parse(strings.NewReader(input), allow); err != nil {
+			t.Errorf("Parse(%q) with sqrt and abs allowed failed: %v", input, err)
+		}
+	}
+	for _, input := range []string{"max(1, 2)", "sqrt(min(4, 9))"} {
+		if _, err := Parse(strings.NewReader(input), allow); err == nil || !strings.Contains(err.Error(), "not allowed") {
+			t.Errorf("Parse(%q) with sqrt and abs allowed returned %v, want error", input, err)
+		}
+		if _, err := EvalParse(strings.NewReader(input), allow); err == nil {
+			t.Errorf("EvalParse(%q) with sqrt and abs allowed succeeded, want error", input)
+		}
+	}
+	if _, err := Parse(strings.NewReader("abs(1)"), WithAllowedFunctions()); err == nil {
+		t.Error("Parse of a call with no function allowed succeeded, want error")
+	}
+}
+
+func TestParseCallDecimalComma(t *testing.T) {
+	e, err := Parse(strings.NewReader("min(1,5; 2)"), WithDecimalComma())
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got, _ := e.Eval(); got != 1.5 {
+		t.Errorf("min(1,5; 2) = %v, want 1.5", got)
+	}
+}
+
+func TestCallTraversal(t *testing.T) {
+	e := mustParse(t, "1 + max(2, 3 * 4, -5)")
+	if got := len(Find(e, func(e Expr) bool { return KindOf(e) == NumberKind })); got != 5 {
+		t.Errorf("Find found %d numbers, want 5", got)
+	}
+	if s := ExprStats(e); s.Nodes != e.Len() || s.Depth != 4 {
+		t.Errorf("ExprStats = %d nodes of depth %d, want %d of depth 4", s.Nodes, s.Depth, e.Len())
+	}
+	if !IsConstant(e) {
+		t.Error("IsConstant = false, want true")
+	}
+}
+
+func TestRegisterFunction(t *testing.T) {
+	registerClamp(t)
+	fn := func(args []float64) (float64, error) { return 0, nil }
+	for _, name := range []string{"sqrt", "clamp", "2x", ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterFunction(%q) did not panic", name)
+				}
+			}()
+			RegisterFunction(name, 1, fn)
+		}()
+	}
+}
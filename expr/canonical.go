@@ -0,0 +1,195 @@
+package expr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Canonical returns a compact text form of the expression that parses back into the
+// same tree: numbers are written with all their digits and parentheses are only
+// added where the structure requires them. Unlike String, nothing is rounded.
+// A signed number, as built by WithFoldUnaryLiterals, Num or Optimize, is written in
+// parentheses, like (-5), which Parse reads as a number rather than a sign and a number.
+func Canonical(e Expr) string {
+	var b strings.Builder
+	writeCanonical(&b, e)
+	return b.String()
+}
+
+func writeCanonical(b *strings.Builder, e Expr) {
+	switch e := e.(type) {
+	case num:
+		writeSigned(b, FormatCanonical(float64(e)), math.Signbit(float64(e)))
+	case literal:
+		writeSigned(b, e.text, strings.HasPrefix(e.text, "-") || strings.HasPrefix(e.text, "+"))
+	case constant:
+		b.WriteString(string(e))
+	case unary:
+		b.WriteRune(e.op)
+		writeOperand(b, e.x, needParens(e, e.x, false), writeCanonical)
+	case binary:
+		writeOperand(b, e.x, needParens(e, e.x, false), writeCanonical)
+		b.WriteString(" ")
+		b.WriteRune(e.op)
+		b.WriteString(" ")
+		writeOperand(b, e.y, needParens(e, e.y, true), writeCanonical)
+	case call:
+		writeCall(b, e, writeCanonical)
+	default:
+		b.WriteString(e.String())
+	}
+}
+
+// RPN returns the expression in reverse Polish notation, every operator after its
+// operands, like "1 2 3 * +" for 1 + 2 * 3. Numbers are written as in Canonical. The signs
+// are written as neg and pos, to tell them apart from the binary operators '-' and '+'.
+// Function calls, whose number of arguments may vary, are written as in Canonical.
+func RPN(e Expr) string {
+	var b strings.Builder
+	writeRPN(&b, e)
+	return b.String()
+}
+
+func writeRPN(b *strings.Builder, e Expr) {
+	switch e := e.(type) {
+	case unary:
+		writeRPN(b, e.x)
+		switch e.op {
+		case '-':
+			b.WriteString(" neg")
+		case '+':
+			b.WriteString(" pos")
+		default:
+			b.WriteString(" ")
+			b.WriteRune(e.op)
+		}
+	case binary:
+		writeRPN(b, e.x)
+		b.WriteString(" ")
+		writeRPN(b, e.y)
+		b.WriteString(" ")
+		b.WriteRune(e.op)
+	case num:
+		b.WriteString(FormatCanonical(float64(e))) // a sign in front of a number cannot be an operator here
+	case literal:
+		b.WriteString(e.text)
+	default:
+		writeCanonical(b, e)
+	}
+}
+
+// needParens reports whether the operand x of the operation parent has to be put in
+// parentheses to keep the structure of the tree when it is parsed back.
+// right tells if x is the right operand of a binary.
+func needParens(parent, x Expr, right bool) bool {
+	if p, ok := parent.(binary); ok && p.op == '^' && !right {
+		// a sign in front of the base applies to the whole power: -2 ^ 2 is -(2 ^ 2)
+		if _, ok := x.(unary); ok {
+			return true
+		}
+	}
+	b, ok := x.(binary)
+	if !ok {
+		return false
+	}
+	switch parent := parent.(type) {
+	case unary:
+		return true
+	case binary:
+		// '^' binds tightest and associates to the right,
+		// so only a power as its right operand goes without parentheses
+		if parent.op == '^' {
+			return !right || priority(b.op) < priority(parent.op)
+		}
+		// the other operators of the same priority associate to the left,
+		// so only the right operand needs parentheses in that case
+		if right {
+			return priority(b.op) <= priority(parent.op)
+		}
+		return priority(b.op) < priority(parent.op)
+	}
+	return false
+}
+
+// writeSigned writes the text of a number, in parentheses if it has a sign.
+func writeSigned(b *strings.Builder, text string, signed bool) {
+	if signed {
+		b.WriteString("(")
+		b.WriteString(text)
+		b.WriteString(")")
+		return
+	}
+	b.WriteString(text)
+}
+
+// writeCall writes a call with its arguments written by the given writer.
+func writeCall(b *strings.Builder, c call, write func(*strings.Builder, Expr)) {
+	b.WriteString(c.name)
+	b.WriteString("(")
+	for i, a := range c.args {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		write(b, a)
+	}
+	b.WriteString(")")
+}
+
+// writeOperand writes an operand with the given writer, in parentheses if requested.
+func writeOperand(b *strings.Builder, x Expr, parens bool, write func(*strings.Builder, Expr)) {
+	if parens {
+		b.WriteString("(")
+		write(b, x)
+		b.WriteString(")")
+		return
+	}
+	write(b, x)
+}
+
+// SaveExprs writes the expressions in canonical form, one per line,
+// so that they can be read back with LoadExprs without the original source.
+// Expressions containing infinite or NaN numbers have no text form and are rejected.
+func SaveExprs(w io.Writer, exprs []Expr) error {
+	bw := bufio.NewWriter(w)
+	for i, e := range exprs {
+		var bad error
+		Walk(e, func(e Expr) bool {
+			if n, ok := numValue(e); ok && (math.IsInf(float64(n), 0) || math.IsNaN(float64(n))) {
+				bad = fmt.Errorf("expression %d contains the number %g which cannot be saved", i, float64(n))
+			}
+			return bad == nil
+		})
+		if bad != nil {
+			return bad
+		}
+		if _, err := fmt.Fprintln(bw, Canonical(e)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// LoadExprs reads expressions written by SaveExprs, one per line. Blank lines are skipped.
+func LoadExprs(r io.Reader) ([]Expr, error) {
+	var exprs []Expr
+	br := bufio.NewReader(r) // lines of large expressions may be longer than a bufio.Scanner allows
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if strings.TrimSpace(line) != "" {
+			e, perr := Parse(strings.NewReader(line))
+			if perr != nil {
+				return nil, fmt.Errorf("line %d: %s", n, perr)
+			}
+			exprs = append(exprs, e)
+		}
+		if err == io.EOF {
+			return exprs, nil
+		}
+	}
+}
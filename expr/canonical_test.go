@@ -0,0 +1,91 @@
+package expr
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"1 + 2 * 3", "1 + 2 * 3"},
+		{"(1 + 2) * 3", "(1 + 2) * 3"},
+		{"1 - (2 - 3)", "1 - (2 - 3)"},
+		{"(1 - 2) - 3", "1 - 2 - 3"},
+		{"1 + (2 + 3)", "1 + (2 + 3)"}, // keeps the structure of the tree
+		{"-(1 + 2) * --3", "-(1 + 2) * --3"},
+		{"0.1 + 1.005e-20", "0.1 + 1.005e-20"},
+		{"((2))", "2"},
+	}
+	for _, test := range tests {
+		if got := Canonical(mustParse(t, test.input)); got != test.want {
+			t.Errorf("Canonical(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestSaveLoadExprsRoundTrip(t *testing.T) {
+	content, err := os.ReadFile("../testdata/1k.txt")
+	if err != nil {
+		t.Fatalf("could not read file: %v", err)
+	}
+	var exprs []Expr
+	for _, input := range []string{string(content), "1 - (2 - 3)", "-(0.1 + 0.2) / 3", "7"} {
+		exprs = append(exprs, mustParse(t, input))
+	}
+
+	var buf bytes.Buffer
+	if err := SaveExprs(&buf, exprs); err != nil {
+		t.Fatalf("SaveExprs failed: %v", err)
+	}
+	loaded, err := LoadExprs(&buf)
+	if err != nil {
+		t.Fatalf("LoadExprs failed: %v", err)
+	}
+	if len(loaded) != len(exprs) {
+		t.Fatalf("loaded %d expressions, want %d", len(loaded), len(exprs))
+	}
+	for i := range exprs {
+		if loaded[i] != exprs[i] {
+			t.Errorf("expression %d changed in the round trip: %s", i, Canonical(loaded[i]))
+		}
+	}
+}
+
+func TestSaveExprsRejectsInfinity(t *testing.T) {
+	var buf bytes.Buffer
+	if err := SaveExprs(&buf, []Expr{binary{'+', num(1), num(math.Inf(1))}}); err == nil {
+		t.Error("SaveExprs succeeded on an infinite number, want error")
+	}
+}
+
+func TestLoadExprsReportsLine(t *testing.T) {
+	_, err := LoadExprs(strings.NewReader("1 + 2\n\n3 *\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("LoadExprs error = %v, want error on line 3", err)
+	}
+}
+
+func TestRPN(t *testing.T) {
+	registerSqrt(t)
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"1+2*3", "1 2 3 * +"},
+		{"(1 + 2) * 3", "1 2 + 3 *"},
+		{"1 - 2 - 3", "1 2 - 3 -"},
+		{"-(0.5 / +4)", "0.5 4 pos / neg"},
+		{"√9", "9 √"},
+	}
+	for _, test := range tests {
+		if got := RPN(mustParse(t, test.input)); got != test.want {
+			t.Errorf("RPN(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package expr
+
+// A Compiled expression has its constant parts computed once, so that it can be evaluated
+// many times with different values of its variables at the cost of the variable parts
+// only. It is made by Compile and safe for concurrent use.
+type Compiled struct {
+	e Expr // the reduced tree
+}
+
+// Compile computes every subexpression that does not depend on a variable, like the
+// 2 * pi of 2 * pi * r, and keeps the reduced tree for Eval. Subexpressions whose
+// evaluation fails are kept as they are, so that Eval reports their error.
+func Compile(e Expr) *Compiled {
+	reduced, _ := reduce(e)
+	return &Compiled{reduced}
+}
+
+// Eval evaluates the compiled expression like EvalEnv, with the variables taking their
+// values from env.
+func (c *Compiled) Eval(env map[string]float64) (float64, error) {
+	return EvalEnv(c.e, env)
+}
+
+// Len returns the number of nodes left after the reduction.
+func (c *Compiled) Len() int {
+	return c.e.Len()
+}
+
+// EvalAfterReduce compiles the expression and evaluates it with env. To evaluate the same
+// expression many times, Compile it once and call Eval instead.
+func EvalAfterReduce(e Expr, env map[string]float64) (float64, error) {
+	return Compile(e).Eval(env)
+}
+
+// reduce returns e with its constant subexpressions replaced by their values, and
+// whether e is constant itself.
+func reduce(e Expr) (Expr, bool) {
+	switch e := e.(type) {
+	case unary:
+		x, ok := reduce(e.x)
+		return fold(unary{e.op, x}, ok)
+	case binary:
+		x, okx := reduce(e.x)
+		y, oky := reduce(e.y)
+		return fold(binary{e.op, x, y}, okx && oky)
+	case call:
+		c := call{e.name, make([]Expr, len(e.args))}
+		fixed := true
+		for i, a := range e.args {
+			var ok bool
+			c.args[i], ok = reduce(a)
+			fixed = fixed && ok
+		}
+		return fold(c, fixed)
+	}
+	return e, IsConstant(e)
+}
+
+// fold returns the value of e if it is constant and can be evaluated, and e otherwise.
+func fold(e Expr, fixed bool) (Expr, bool) {
+	if !fixed {
+		return e, false
+	}
+	v, err := e.Eval()
+	if err != nil {
+		return e, false
+	}
+	return num(v), true
+}
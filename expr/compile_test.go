@@ -0,0 +1,76 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		input string
+		len   int // Len of the reduced tree
+	}{
+		{"2 * pi * r", 3},
+		{"r * (2 * pi)", 3},
+		{"(1 + 2) * r + sqrt(16)", 5},
+		{"max(r, 1 + 1, 3)", 4},
+		{"3 * 4", 1},
+		{"r", 1},
+	}
+	for _, test := range tests {
+		e := mustParse(t, test.input)
+		c := Compile(e)
+		if c.Len() != test.len {
+			t.Errorf("Len of compiled %q = %d, want %d", test.input, c.Len(), test.len)
+		}
+		for _, r := range []float64{0, 1.5, -2} {
+			env := map[string]float64{"r": r}
+			want, _ := EvalEnv(e, env)
+			if got, err := c.Eval(env); err != nil || got != want {
+				t.Errorf("compiled %q with r = %v is %v, %v, want %v", test.input, r, got, err, want)
+			}
+			if got, err := EvalAfterReduce(e, env); err != nil || got != want {
+				t.Errorf("EvalAfterReduce(%q) with r = %v is %v, %v, want %v", test.input, r, got, err, want)
+			}
+		}
+	}
+
+	// a failing part is kept, and fails every evaluation
+	c := Compile(mustParse(t, "r + 1 / (2 - 2)"))
+	if _, err := c.Eval(map[string]float64{"r": 1}); err == nil || !strings.Contains(err.Error(), "division by zero") {
+		t.Errorf("compiled division by zero returned %v, want error", err)
+	}
+	if _, err := Compile(mustParse(t, "2 * pi * r")).Eval(nil); err == nil {
+		t.Error("compiled expression without a value for r succeeded, want error")
+	}
+}
+
+func BenchmarkEvalEnv(b *testing.B) {
+	benchmarkEnv(b, func(e Expr) func(map[string]float64) (float64, error) {
+		return func(env map[string]float64) (float64, error) { return EvalEnv(e, env) }
+	})
+}
+
+func BenchmarkCompiled(b *testing.B) {
+	benchmarkEnv(b, func(e Expr) func(map[string]float64) (float64, error) {
+		return Compile(e).Eval
+	})
+}
+
+// benchmarkEnv evaluates a mostly constant expression many times with a varying x.
+func benchmarkEnv(b *testing.B, prepare func(Expr) func(map[string]float64) (float64, error)) {
+	src := strings.Repeat("sqrt(2) * pi + 3 ^ 4 / 7 - ", 50) + "x"
+	e, err := Parse(strings.NewReader(src))
+	if err != nil {
+		b.Fatal(err)
+	}
+	eval := prepare(e)
+	env := map[string]float64{}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		env["x"] = float64(i)
+		if _, err := eval(env); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
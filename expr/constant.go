@@ -0,0 +1,27 @@
+package expr
+
+import "math"
+
+// constants holds the named numbers that expressions can refer to, like pi.
+var constants = map[string]float64{
+	"pi":  math.Pi,
+	"tau": 2 * math.Pi,
+	"e":   math.E,
+}
+
+// A constant is a named number like pi. Unlike a num, it keeps its name: it prints as
+// written, and the exact evaluators can tell that its value is only an approximation.
+type constant string // a key of constants
+
+func (c constant) Eval() (float64, error) {
+	return constants[string(c)], nil
+}
+func (c constant) String() string {
+	return string(c)
+}
+func (c constant) Len() int {
+	return 1
+}
+func (c constant) Cost() int {
+	return 1
+}
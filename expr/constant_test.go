@@ -0,0 +1,88 @@
+package expr
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseConstants(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"pi", math.Pi},
+		{"2 * pi * 3", 2 * math.Pi * 3},
+		{"tau / 2", math.Pi},
+		{"log(e)", 1},
+		{"e ^ 2", math.Pow(math.E, 2)},
+		{"-pi", -math.Pi},
+	}
+	for _, test := range tests {
+		for _, parse := range []func(string) (Expr, error){
+			func(s string) (Expr, error) { return Parse(strings.NewReader(s)) },
+			func(s string) (Expr, error) { return EvalParse(strings.NewReader(s)) },
+		} {
+			e, err := parse(test.input)
+			if err != nil {
+				t.Fatalf("could not parse %q: %v", test.input, err)
+			}
+			if got, err := e.Eval(); err != nil || got != test.want {
+				t.Errorf("%q = %v, %v, want %v", test.input, got, err, test.want)
+			}
+		}
+	}
+
+	// constants keep their name in the tree
+	if e := mustParse(t, "2 * pi"); e.Len() != 3 || !IsConstant(e) || KindOf(e.(binary).y) != NumberKind {
+		t.Errorf("2 * pi has %d nodes, want 3 numbers and operators", e.Len())
+	}
+	for _, input := range []string{"2 * pi", "tau / (e + 1)"} {
+		if got := Canonical(mustParse(t, input)); got != input {
+			t.Errorf("Canonical(%q) = %q, want it unchanged", input, got)
+		}
+	}
+	// other names are variables
+	for _, input := range []string{"pie", "E"} {
+		if k := KindOf(mustParse(t, input)); k != VariableKind {
+			t.Errorf("%q parses as a %s, want a variable", input, k)
+		}
+	}
+	if _, err := Parse(strings.NewReader("pi(2)")); err == nil {
+		t.Error("Parse(\"pi(2)\") succeeded, want error")
+	}
+}
+
+func TestConstantsAreNotExact(t *testing.T) {
+	if r, err := EvalRat(mustParse(t, "2 * pi")); err == nil {
+		t.Errorf("EvalRat(2 * pi) = %v, want error", r)
+	}
+	if f, err := EvalFraction(mustParse(t, "pi")); err == nil {
+		t.Errorf("EvalFraction(pi) = %v, want error", f)
+	}
+	// the approximation still shows, unlike a number written with the same digits
+	if _, ulps, err := EvalTracked(mustParse(t, "pi")); err != nil || ulps == 0 {
+		t.Errorf("EvalTracked(pi) has an error of %v ULPs, %v, want more than 0", ulps, err)
+	}
+	if Same(mustParse(t, "pi"), Num(math.Pi)) {
+		t.Error("pi is the same as its value, want them to differ")
+	}
+}
+
+func TestMarshalConstants(t *testing.T) {
+	e := mustParse(t, "2 * pi + e")
+	data, err := MarshalBinary(e)
+	if err != nil {
+		t.Fatalf("MarshalBinary(%v) failed: %v", e, err)
+	}
+	back, err := UnmarshalBinary(data)
+	if err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+	if !Same(e, back) {
+		t.Errorf("UnmarshalBinary gave %v, want %v", back, e)
+	}
+	if _, err := UnmarshalBinary([]byte{'C', 'A', binaryVersion, tagConstant, 3, 'p', 'h', 'i'}); err == nil {
+		t.Error("UnmarshalBinary of an unknown constant succeeded, want error")
+	}
+}
@@ -0,0 +1,91 @@
+package expr
+
+import "fmt"
+
+// opCost returns the relative weight of an operator when estimating the evaluation expense.
+// Additions and signs are the unit of cost, powers are the most expensive operation.
+func opCost(op rune) int {
+	switch op {
+	case '^':
+		return 8
+	case '/', '%':
+		return 4
+	case '*':
+		return 2
+	case '+', '-':
+		return 1
+	}
+	return 0
+}
+
+// callCost is the weight of a function call, that of the most expensive operator.
+const callCost = 8
+
+// EstimatedCost returns a rough estimate of the expense of evaluating the expression,
+// as the sum of the weights of all its operations. Numbers are free, unlike in Cost.
+// It can be used to reject overly expensive expressions before evaluating them.
+func EstimatedCost(e Expr) int {
+	cost := 0
+	Walk(e, func(e Expr) bool {
+		switch e := e.(type) {
+		case unary:
+			cost += opCost(e.op)
+		case binary:
+			cost += opCost(e.op)
+		case call:
+			cost += callCost
+		}
+		return true
+	})
+	return cost
+}
+
+// Usage is the breakdown of an expression for metering its evaluation.
+type Usage struct {
+	Operations   int // unary and binary arithmetic operations and function calls
+	Calls        int // function calls alone
+	Literals     int // numbers
+	ComputeUnits int // weighted total of the operations, as given by EstimatedCost
+}
+
+// Meter counts what evaluating the expression takes, for billing or quotas.
+func Meter(e Expr) Usage {
+	var u Usage
+	Walk(e, func(e Expr) bool {
+		switch e := e.(type) {
+		case num, literal, constant:
+			u.Literals++
+		case unary:
+			u.Operations++
+			u.ComputeUnits += opCost(e.op)
+		case binary:
+			u.Operations++
+			u.ComputeUnits += opCost(e.op)
+		case call:
+			u.Operations++
+			u.Calls++
+			u.ComputeUnits += callCost
+		}
+		return true
+	})
+	return u
+}
+
+// EvalLimited evaluates the expression like Eval, but fails without evaluating anything
+// when it takes more than maxOps unary and binary operations and function calls. It is
+// meant for untrusted input: the operations are counted first, and the count stops at
+// the limit.
+func EvalLimited(e Expr, maxOps int) (float64, error) {
+	ops := 0
+	Walk(e, func(e Expr) bool {
+		switch e.(type) {
+		case unary, binary, call:
+			ops++
+		}
+		return ops <= maxOps
+	})
+	if ops > maxOps {
+		return 0, fmt.Errorf("expression exceeds the limit of %d operations", maxOps)
+	}
+	return e.Eval()
+}
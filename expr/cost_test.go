@@ -0,0 +1,105 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimatedCost(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"1", 0},
+		{"-1", 1},
+		{"1 + 2", 1},
+		{"1 * 2", 2},
+		{"1 / 2", 4},
+		{"-(1 + 2) / 3", 6},
+	}
+	for _, test := range tests {
+		e, err := Parse(strings.NewReader(test.input))
+		if err != nil {
+			t.Fatalf("could not parse %q: %v", test.input, err)
+		}
+		if got := EstimatedCost(e); got != test.want {
+			t.Errorf("EstimatedCost(%q) = %d, want %d", test.input, got, test.want)
+		}
+	}
+}
+
+func TestEstimatedCostDivisionIsMoreExpensive(t *testing.T) {
+	sum, _ := Parse(strings.NewReader("1 + 2"))
+	div, _ := Parse(strings.NewReader("1 / 2"))
+	if EstimatedCost(sum) >= EstimatedCost(div) {
+		t.Errorf("cost of %v (%d) should be lower than cost of %v (%d)",
+			sum, EstimatedCost(sum), div, EstimatedCost(div))
+	}
+}
+
+func TestMeter(t *testing.T) {
+	e, err := Parse(strings.NewReader("-(1 + 2) * 3 / 4 - 5"))
+	if err != nil {
+		t.Fatalf("could not parse: %v", err)
+	}
+	want := Usage{Operations: 5, Literals: 5, ComputeUnits: 1 + 1 + 2 + 4 + 1}
+	got := Meter(e)
+	if got != want {
+		t.Errorf("Meter = %+v, want %+v", got, want)
+	}
+	if got.ComputeUnits != EstimatedCost(e) {
+		t.Errorf("ComputeUnits = %d, want EstimatedCost = %d", got.ComputeUnits, EstimatedCost(e))
+	}
+
+	want = Usage{Operations: 3, Calls: 2, Literals: 3, ComputeUnits: 8 + 8 + 1}
+	if got := Meter(mustParse(t, "max(1, sqrt(2) + 3)")); got != want {
+		t.Errorf("Meter with calls = %+v, want %+v", got, want)
+	}
+}
+
+func TestEvalLimited(t *testing.T) {
+	e := mustParse(t, "-(1 + 2) * 3")
+	if got, err := EvalLimited(e, 3); err != nil || got != -9 {
+		t.Errorf("EvalLimited with 3 operations = %v, %v, want -9", got, err)
+	}
+	if _, err := EvalLimited(e, 2); err == nil {
+		t.Error("EvalLimited with a limit of 2 succeeded, want error")
+	}
+	if _, err := EvalLimited(mustParse(t, "sqrt(sqrt(16))"), 1); err == nil {
+		t.Error("EvalLimited of two calls with a limit of 1 succeeded, want error")
+	}
+	if _, err := EvalLimited(mustParse(t, "1 / (2 - 2)"), 10); err == nil {
+		t.Error("EvalLimited of a division by zero succeeded, want error")
+	}
+}
+
+func TestCost(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"1", 1},
+		{"-1", 2},
+		{"1 + 2 - 3", 5},
+		{"1 / 2 / 3", 11},
+		{"-(1 + 2) / 3", 9},
+		{"sqrt(sqrt(sqrt(2)))", 25},
+		{"max(1, 2 * 3)", 13},
+	}
+	for _, test := range tests {
+		e := mustParse(t, test.input)
+		if got := e.Cost(); got != test.want {
+			t.Errorf("Cost of %q = %d, want %d", test.input, got, test.want)
+		}
+		// the numbers make the difference to the estimate
+		if got := e.Cost() - Meter(e).Literals; got != EstimatedCost(e) {
+			t.Errorf("Cost of %q without numbers = %d, want EstimatedCost %d", test.input, got, EstimatedCost(e))
+		}
+	}
+
+	// as many nodes, but divisions instead of additions
+	cheap, expensive := mustParse(t, "1 + 2 + 3 + 4"), mustParse(t, "1 / 2 / 3 / 4")
+	if cheap.Len() != expensive.Len() || cheap.Cost() >= expensive.Cost() {
+		t.Errorf("Cost of additions %d is not below the Cost of divisions %d", cheap.Cost(), expensive.Cost())
+	}
+}
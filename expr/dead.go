@@ -0,0 +1,88 @@
+package expr
+
+import "math"
+
+// DeadSubexprs reports the subexpressions that cannot affect the result because
+// they are multiplied by zero, like the 3 + 4 in 0 * (3 + 4). They often point to
+// mistakes in a formula. Only the outermost dead subexpressions are reported.
+//
+// Multiplying by zero does not hide everything: 0 * Inf is NaN and a division by
+// zero fails the whole evaluation. So an operand is only considered dead when it
+// evaluates without error to a finite number, or when it cannot be evaluated yet
+// because of its variables, like the x in 0 * x. If both operands are zero, the right
+// one is reported.
+func DeadSubexprs(e Expr) []Expr {
+	var dead []Expr
+	collectDead(e, &dead)
+	return dead
+}
+
+// valueState is what collectDead knows of the value of a subexpression.
+type valueState int
+
+// The states are ordered: a node with several operands has the largest of their states.
+const (
+	finite  valueState = iota // evaluated to a finite number
+	unbound                   // depends on variables, which have no value yet
+	invalid                   // fails to evaluate or is not finite
+)
+
+// collectDead evaluates e, appending the dead subexpressions found along the way,
+// and returns the value of e, which is only meaningful if its state is finite.
+func collectDead(e Expr, dead *[]Expr) (float64, valueState) {
+	switch e := e.(type) {
+	case variable:
+		return 0, unbound
+
+	case unary:
+		x, state := collectDead(e.x, dead)
+		if state != finite {
+			return 0, state
+		}
+		return checkFinite(unary{e.op, num(x)}.Eval())
+
+	case binary:
+		mark := len(*dead)
+		x, sx := collectDead(e.x, dead)
+		mid := len(*dead)
+		y, sy := collectDead(e.y, dead)
+		if sx == invalid || sy == invalid {
+			return 0, invalid
+		}
+		if e.op == '*' {
+			switch {
+			case sx == finite && x == 0:
+				// anything reported inside the right operand is dead anyway
+				*dead = append((*dead)[:mid], e.y)
+			case sy == finite && y == 0:
+				*dead = append((*dead)[:mark], append([]Expr{e.x}, (*dead)[mid:]...)...)
+			}
+		}
+		if sx == unbound || sy == unbound {
+			return 0, unbound
+		}
+		return checkFinite(binary{e.op, num(x), num(y)}.Eval())
+
+	case call:
+		bound := call{e.name, make([]Expr, len(e.args))}
+		state := finite
+		for i, a := range e.args {
+			x, s := collectDead(a, dead)
+			state = max(state, s)
+			bound.args[i] = num(x)
+		}
+		if state != finite {
+			return 0, state
+		}
+		return checkFinite(bound.Eval())
+	}
+	return checkFinite(e.Eval())
+}
+
+// checkFinite returns the state of a value computed by collectDead.
+func checkFinite(v float64, err error) (float64, valueState) {
+	if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, invalid
+	}
+	return v, finite
+}
@@ -0,0 +1,41 @@
+package expr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeadSubexprs(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"1 + 2", nil},
+		{"0 * (3 + 4)", []string{"3.00 + 4.00"}},
+		{"(3 + 4) * (2 - 2) + 1", []string{"3.00 + 4.00"}},
+		{"0 * (5 * (1 - 1))", []string{"5.00 * 1.00 - 1.00"}}, // only the outermost
+		{"(0 * 7) * 1 + 9 * (2 - 2)", []string{"7.00", "1.00", "9.00"}},
+		{"0 * 0", []string{"0.00"}},
+		{"0 * (1 / 0)", nil},      // the division by zero still fails
+		{"0 * (1e308 * 10)", nil}, // 0 * Inf is NaN
+		{"(1 - 1) / 2", nil},      // only products hide their operands
+		{"0 * x", []string{"x"}},
+		{"(x + 1) * (2 - 2)", []string{"x + 1.00"}},
+		{"0 * x + y * (1 - 1)", []string{"x", "y"}},
+		{"x * (1 / 0)", nil},
+		{"sqrt(0 * (3 + 4))", []string{"3.00 + 4.00"}},
+		{"max(0 * 2, x * 0) * 5", []string{"2.00", "x"}},
+		{"0 * sqrt(9)", []string{"sqrt(9.00)"}},
+		{"0 * sqrt(-1)", nil}, // NaN
+		{"0 * sqrt(x)", []string{"sqrt(x)"}},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, d := range DeadSubexprs(mustParse(t, test.input)) {
+			got = append(got, d.String())
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("DeadSubexprs(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package expr
+
+import (
+	"fmt"
+	"time"
+)
+
+// deadlineCheckInterval is the number of operations EvalDeadline evaluates between two
+// looks at the clock, so that reading the time does not slow the evaluation down.
+const deadlineCheckInterval = 1024
+
+// EvalDeadline evaluates the expression like Eval, but gives up with an error once the
+// evaluation has taken longer than d. It is a simpler alternative to a context for
+// scripts. The deadline is soft: the clock is only read every 1024 operations, and a
+// node not built by Parse is evaluated as a whole by its own Eval.
+func EvalDeadline(e Expr, d time.Duration) (float64, error) {
+	ev := deadlineEval{deadline: time.Now().Add(d)}
+	res, err := ev.eval(e)
+	if ev.expired {
+		return 0, fmt.Errorf("evaluation exceeded the deadline of %v", d)
+	}
+	return res, err
+}
+
+// deadlineEval evaluates an expression while watching the clock.
+type deadlineEval struct {
+	deadline time.Time
+	ops      int
+	expired  bool
+}
+
+func (ev *deadlineEval) eval(e Expr) (float64, error) {
+	switch e := e.(type) {
+	case unary:
+		x, err := ev.eval(e.x)
+		if err != nil || ev.tick() {
+			return 0, err
+		}
+		return unary{e.op, num(x)}.Eval()
+
+	case binary:
+		x, err := ev.eval(e.x)
+		if err != nil {
+			return 0, err
+		}
+		y, err := ev.eval(e.y)
+		if err != nil || ev.tick() {
+			return 0, err
+		}
+		res, err := binary{e.op, num(x), num(y)}.Eval()
+		if err != nil {
+			return 0, fmt.Errorf("evaluation of %v failed: %s", e, err)
+		}
+		return res, nil
+
+	case call:
+		bound := call{e.name, make([]Expr, len(e.args))}
+		for i, a := range e.args {
+			x, err := ev.eval(a)
+			if err != nil {
+				return 0, err
+			}
+			bound.args[i] = num(x)
+		}
+		if ev.tick() {
+			return 0, nil
+		}
+		return bound.Eval()
+	}
+	return e.Eval()
+}
+
+// tick counts an operation and reports whether the deadline has passed.
+// Once it has, every further operation is abandoned right away.
+func (ev *deadlineEval) tick() bool {
+	if ev.expired {
+		return true
+	}
+	ev.ops++
+	if ev.ops%deadlineCheckInterval == 0 && time.Now().After(ev.deadline) {
+		ev.expired = true
+	}
+	return ev.expired
+}
@@ -0,0 +1,36 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvalDeadline(t *testing.T) {
+	got, err := EvalDeadline(mustParse(t, "1 + 2 * 3"), time.Hour)
+	if err != nil || got != 7 {
+		t.Errorf("EvalDeadline = %v, %v, want 7", got, err)
+	}
+
+	huge := mustParse(t, strings.Repeat("1 + ", 100000)+"1")
+	if _, err := EvalDeadline(huge, time.Nanosecond); err == nil {
+		t.Error("EvalDeadline of a huge tree with a tiny deadline succeeded, want timeout")
+	}
+	if got, err := EvalDeadline(huge, time.Hour); err != nil || got != 100001 {
+		t.Errorf("EvalDeadline of a huge tree = %v, %v, want 100001", got, err)
+	}
+
+	// the clock is also watched inside the arguments of calls
+	call := mustParse(t, "max(0, "+strings.Repeat("1 + ", 100000)+"1)")
+	if _, err := EvalDeadline(call, time.Nanosecond); err == nil {
+		t.Error("EvalDeadline of a call of a huge tree with a tiny deadline succeeded, want timeout")
+	}
+	if got, err := EvalDeadline(call, time.Hour); err != nil || got != 100001 {
+		t.Errorf("EvalDeadline of a call of a huge tree = %v, %v, want 100001", got, err)
+	}
+
+	// evaluation errors are reported as by Eval
+	if _, err := EvalDeadline(mustParse(t, "1 / 0"), time.Hour); err == nil {
+		t.Error("EvalDeadline of a division by zero succeeded, want error")
+	}
+}
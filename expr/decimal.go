@@ -0,0 +1,122 @@
+package expr
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// A Rounding tells how a decimal result is rounded to the digits available.
+type Rounding int
+
+const (
+	RoundHalfUp   Rounding = iota // ties away from zero, as taught in school: 2.5 -> 3, -2.5 -> -3
+	RoundHalfEven                 // ties to the even neighbour, banker's rounding: 2.5 -> 2, 3.5 -> 4
+	RoundDown                     // truncate towards zero: 2.9 -> 2, -2.9 -> -2
+)
+
+// EvalDecimal evaluates the expression in fixed-point decimal arithmetic with scale digits
+// after the decimal point, rounding half up, and returns the result with exactly that many
+// decimals. With scale 2, 0.1 + 0.2 is exactly "0.30", as expected for amounts of money.
+func EvalDecimal(e Expr, scale int) (string, error) {
+	return EvalDecimalRounding(e, scale, RoundHalfUp)
+}
+
+// EvalDecimalRounding is like EvalDecimal with the given rounding mode. Numbers, products
+// and quotients are rounded to the scale as soon as they are computed, like on a cash register.
+func EvalDecimalRounding(e Expr, scale int, mode Rounding) (string, error) {
+	if scale < 0 {
+		return "", fmt.Errorf("negative scale %d", scale)
+	}
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)
+	d, err := evalDecimal(e, unit, mode)
+	if err != nil {
+		return "", err
+	}
+	return d.FloatString(scale), nil
+}
+
+func evalDecimal(e Expr, unit *big.Int, mode Rounding) (*big.Rat, error) {
+	switch e := e.(type) {
+	case num, literal:
+		r, err := EvalRat(e)
+		if err != nil {
+			return nil, err
+		}
+		return roundDecimal(r, unit, mode), nil
+
+	case constant:
+		// rounded to the scale like any other number, though it is irrational
+		v, _ := e.Eval()
+		return roundDecimal(new(big.Rat).SetFloat64(v), unit, mode), nil
+
+	case unary:
+		x, err := evalDecimal(e.x, unit, mode)
+		if err != nil {
+			return nil, err
+		}
+		switch e.op {
+		case '+':
+			return x, nil
+		case '-':
+			return x.Neg(x), nil
+		}
+		return nil, fmt.Errorf("unsupported unary operator: %q", e.op)
+
+	case binary:
+		x, err := evalDecimal(e.x, unit, mode)
+		if err != nil {
+			return nil, err
+		}
+		y, err := evalDecimal(e.y, unit, mode)
+		if err != nil {
+			return nil, err
+		}
+		switch e.op {
+		case '+':
+			return x.Add(x, y), nil
+		case '-':
+			return x.Sub(x, y), nil
+		case '*':
+			return roundDecimal(x.Mul(x, y), unit, mode), nil
+		case '/':
+			if y.Sign() == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return roundDecimal(x.Quo(x, y), unit, mode), nil
+		case '%':
+			if y.Sign() == 0 {
+				return nil, fmt.Errorf("modulo by zero")
+			}
+			return ratMod(x, y), nil // exact, the operands are multiples of 1/unit already
+		case '^':
+			p, err := ratPow(x, y)
+			if err != nil {
+				return nil, err
+			}
+			return roundDecimal(p, unit, mode), nil
+		}
+		return nil, fmt.Errorf("unsupported binary operator: %q", e.op)
+	}
+	return nil, fmt.Errorf("cannot evaluate %v as a decimal", e)
+}
+
+// roundDecimal rounds r to a multiple of 1/unit.
+func roundDecimal(r *big.Rat, unit *big.Int, mode Rounding) *big.Rat {
+	n := new(big.Int).Mul(r.Num(), unit)
+	q, rem := new(big.Int).QuoRem(n, r.Denom(), new(big.Int)) // q is truncated towards zero
+
+	if rem.Sign() != 0 && mode != RoundDown {
+		// compare the remainder with half of the denominator
+		twice := new(big.Int).Abs(rem)
+		twice.Lsh(twice, 1)
+		c := twice.Cmp(r.Denom())
+		if c > 0 || (c == 0 && (mode == RoundHalfUp || q.Bit(0) == 1)) {
+			if r.Sign() < 0 {
+				q.Sub(q, big.NewInt(1))
+			} else {
+				q.Add(q, big.NewInt(1))
+			}
+		}
+	}
+	return new(big.Rat).SetFrac(q, unit)
+}
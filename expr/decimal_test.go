@@ -0,0 +1,68 @@
+package expr
+
+import "testing"
+
+func TestEvalDecimal(t *testing.T) {
+	tests := []struct {
+		input string
+		scale int
+		want  string
+	}{
+		{"0.1 + 0.2", 2, "0.30"},
+		{"0.1 + 0.2", 0, "0"},
+		{"10 / 3", 2, "3.33"},
+		{"20 / 3", 2, "6.67"},
+		{"-20 / 3", 2, "-6.67"},
+		{"1.005 * 1", 2, "1.01"}, // 1.005 is not exact in binary, but the literal is read as decimal
+		{"19.99 * 3 - 0.01", 2, "59.96"},
+		{"1 / 8", 3, "0.125"},
+		{"1 / 8", 2, "0.13"},
+	}
+	for _, test := range tests {
+		got, err := EvalDecimal(mustParse(t, test.input), test.scale)
+		if err != nil {
+			t.Fatalf("EvalDecimal(%q, %d) failed: %v", test.input, test.scale, err)
+		}
+		if got != test.want {
+			t.Errorf("EvalDecimal(%q, %d) = %s, want %s", test.input, test.scale, got, test.want)
+		}
+	}
+}
+
+func TestEvalDecimalRounding(t *testing.T) {
+	tests := []struct {
+		input string
+		mode  Rounding
+		want  string
+	}{
+		{"2.5", RoundHalfUp, "3"},
+		{"2.5", RoundHalfEven, "2"},
+		{"3.5", RoundHalfEven, "4"},
+		{"-2.5", RoundHalfUp, "-3"},
+		{"-2.5", RoundHalfEven, "-2"},
+		{"2.9", RoundDown, "2"},
+		{"-2.9", RoundDown, "-2"},
+		{"5 / 2", RoundHalfEven, "2"},
+	}
+	for _, test := range tests {
+		got, err := EvalDecimalRounding(mustParse(t, test.input), 0, test.mode)
+		if err != nil {
+			t.Fatalf("EvalDecimalRounding(%q) failed: %v", test.input, err)
+		}
+		if got != test.want {
+			t.Errorf("EvalDecimalRounding(%q, %d) = %s, want %s", test.input, test.mode, got, test.want)
+		}
+	}
+}
+
+func TestEvalDecimalErrors(t *testing.T) {
+	if _, err := EvalDecimal(mustParse(t, "1 / (0.001 * 1)"), 2); err == nil {
+		t.Error("EvalDecimal succeeded on a divisor rounded to zero, want division by zero error")
+	}
+	if _, err := EvalDecimal(mustParse(t, "1"), -1); err == nil {
+		t.Error("EvalDecimal succeeded with a negative scale")
+	}
+	if _, err := EvalDecimal(mustParse(t, "(10 ^ 1000) ^ 1000"), 2); err == nil {
+		t.Error("EvalDecimal succeeded on a power with a million digits")
+	}
+}
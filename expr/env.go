@@ -0,0 +1,66 @@
+package expr
+
+import "fmt"
+
+// A variable is a name standing for a number that is only known when evaluating,
+// bound by EvalEnv. Parse produces it for every identifier that is neither a constant
+// like pi nor a function.
+type variable string
+
+// Eval fails, a variable has a value only in the environment of EvalEnv.
+func (v variable) Eval() (float64, error) {
+	return 0, fmt.Errorf("undefined variable %s", string(v))
+}
+func (v variable) String() string {
+	return string(v)
+}
+func (v variable) Len() int {
+	return 1
+}
+func (v variable) Cost() int {
+	return 1
+}
+
+// EvalEnv evaluates the expression like Eval, with the variables taking their values from
+// env, so that "2 * pi * r" can be evaluated for any r. A variable missing from env makes
+// the evaluation fail with an error naming it. The tree is not copied, the environment
+// is looked up while evaluating.
+func EvalEnv(e Expr, env map[string]float64) (float64, error) {
+	switch e := e.(type) {
+	case variable:
+		if x, ok := env[string(e)]; ok {
+			return x, nil
+		}
+		return e.Eval()
+
+	case unary:
+		x, err := EvalEnv(e.x, env)
+		if err != nil {
+			return 0, err
+		}
+		return unary{e.op, num(x)}.Eval()
+
+	case binary:
+		x, err := EvalEnv(e.x, env)
+		if err != nil {
+			return 0, err
+		}
+		y, err := EvalEnv(e.y, env)
+		if err != nil {
+			return 0, err
+		}
+		return binary{e.op, num(x), num(y)}.Eval()
+
+	case call:
+		bound := call{e.name, make([]Expr, len(e.args))}
+		for i, a := range e.args {
+			x, err := EvalEnv(a, env)
+			if err != nil {
+				return 0, err
+			}
+			bound.args[i] = num(x)
+		}
+		return bound.Eval()
+	}
+	return e.Eval()
+}
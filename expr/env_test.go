@@ -0,0 +1,52 @@
+package expr
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEvalEnv(t *testing.T) {
+	env := map[string]float64{"r": 2, "x": 3, "rate_2": 0.5}
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"2 * pi * r", 4 * math.Pi},
+		{"x ^ 2 - 2 * x + 1", 4},
+		{"max(x, r) * rate_2", 1.5},
+		{"-x", -3},
+		{"x % r + sqrt(x * 3)", 4},
+	}
+	for _, test := range tests {
+		e := mustParse(t, test.input)
+		if IsConstant(e) {
+			t.Errorf("IsConstant(%q) = true, want false", test.input)
+		}
+		if got, err := EvalEnv(e, env); err != nil || got != test.want {
+			t.Errorf("EvalEnv(%q) = %v, %v, want %v", test.input, got, err, test.want)
+		}
+	}
+
+	e := mustParse(t, "x + y")
+	_, err := EvalEnv(e, env)
+	if err == nil || !strings.Contains(err.Error(), "undefined variable y") {
+		t.Errorf("EvalEnv with y missing returned %v, want an error naming y", err)
+	}
+	if _, err := e.Eval(); err == nil {
+		t.Error("Eval of an expression with variables succeeded, want error")
+	}
+	if Canonical(e) != "x + y" {
+		t.Errorf("Canonical = %q, want x + y", Canonical(e))
+	}
+	if got := Find(e, func(e Expr) bool { return KindOf(e) == VariableKind }); len(got) != 2 {
+		t.Errorf("Find found %v, want the variables x and y", got)
+	}
+
+	if _, err := Parse(strings.NewReader("f(x)")); err == nil {
+		t.Error("Parse of an unknown function succeeded, want error")
+	}
+	if _, err := EvalParse(strings.NewReader("x + 1")); err == nil {
+		t.Error("EvalParse of a variable succeeded, want error")
+	}
+}
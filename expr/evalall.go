@@ -0,0 +1,61 @@
+package expr
+
+import (
+	"context"
+	"runtime"
+)
+
+// EvalAll evaluates a batch of expressions concurrently and returns their results and
+// errors, at the same index as the expression. When ctx is done before an expression has
+// been evaluated, its error is ctx.Err(); EvalAll does not wait for evaluations still
+// running, so a single slow expression cannot hold up the batch past the deadline.
+// A panicking evaluation is reported as an error, as in EvalWithRecover.
+func EvalAll(ctx context.Context, exprs []Expr) ([]float64, []error) {
+	results := make([]float64, len(exprs))
+	errs := make([]error, len(exprs))
+	finished := make([]bool, len(exprs))
+
+	type result struct {
+		i   int
+		v   float64
+		err error
+	}
+	// buffered, so that workers still running after a timeout never block
+	done := make(chan result, len(exprs))
+	jobs := make(chan int)
+
+	workers := min(runtime.GOMAXPROCS(0), len(exprs))
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				v, err := EvalWithRecover(exprs[i])
+				done <- result{i, v, err}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range exprs {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for range exprs {
+		select {
+		case r := <-done:
+			results[r.i], errs[r.i], finished[r.i] = r.v, r.err, true
+		case <-ctx.Done():
+			for i := range exprs {
+				if !finished[i] {
+					errs[i] = ctx.Err()
+				}
+			}
+			return results, errs
+		}
+	}
+	return results, errs
+}
@@ -0,0 +1,58 @@
+package expr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// slow is a custom node that takes a while to evaluate.
+type slow struct {
+	d time.Duration
+}
+
+func (s slow) Eval() (float64, error) { time.Sleep(s.d); return 1, nil }
+func (s slow) String() string         { return "slow" }
+func (s slow) Len() int               { return 1 }
+func (s slow) Cost() int              { return 1 }
+
+func TestEvalAll(t *testing.T) {
+	exprs := []Expr{
+		mustParse(t, "1 + 2"),
+		mustParse(t, "1 / 0"),
+		panicky{},
+		mustParse(t, "2 * 3"),
+	}
+	results, errs := EvalAll(context.Background(), exprs)
+	if results[0] != 3 || errs[0] != nil {
+		t.Errorf("expression 0 = %g, %v; want 3, nil", results[0], errs[0])
+	}
+	if errs[1] == nil {
+		t.Error("expression 1 succeeded, want division by zero error")
+	}
+	if errs[2] == nil {
+		t.Error("expression 2 succeeded, want error from the panic")
+	}
+	if results[3] != 6 || errs[3] != nil {
+		t.Errorf("expression 3 = %g, %v; want 6, nil", results[3], errs[3])
+	}
+}
+
+func TestEvalAllTimeout(t *testing.T) {
+	exprs := []Expr{mustParse(t, "1 + 2"), slow{time.Hour}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	results, errs := EvalAll(ctx, exprs)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("EvalAll took %v, want it to return at the deadline", elapsed)
+	}
+	if results[0] != 3 || errs[0] != nil {
+		t.Errorf("fast expression = %g, %v; want 3, nil", results[0], errs[0])
+	}
+	if !errors.Is(errs[1], context.DeadlineExceeded) {
+		t.Errorf("slow expression error = %v, want %v", errs[1], context.DeadlineExceeded)
+	}
+}
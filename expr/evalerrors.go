@@ -0,0 +1,73 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+)
+
+// EvalAllErrors evaluates the expression like Eval, but does not stop at the first error:
+// it goes on evaluating the other operands and returns every error found, e.g. all the
+// divisions by zero of a formula at once. When there are errors the result is NaN.
+// An operation whose operands failed is not reported again, only the original errors are.
+func EvalAllErrors(e Expr) (float64, []error) {
+	var errs []error
+	res, ok := evalAllErrors(e, &errs)
+	if !ok {
+		return math.NaN(), errs
+	}
+	return res, nil
+}
+
+// evalAllErrors returns the value of e, or false after appending its errors to errs.
+func evalAllErrors(e Expr, errs *[]error) (float64, bool) {
+	switch e := e.(type) {
+	case unary:
+		x, ok := evalAllErrors(e.x, errs)
+		if !ok {
+			return 0, false
+		}
+		res, err := unary{e.op, num(x)}.Eval()
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("evaluation of %v failed: %s", e, err))
+			return 0, false
+		}
+		return res, true
+
+	case binary:
+		x, okx := evalAllErrors(e.x, errs)
+		y, oky := evalAllErrors(e.y, errs)
+		if !okx || !oky {
+			return 0, false
+		}
+		res, err := binary{e.op, num(x), num(y)}.Eval()
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("evaluation of %v failed: %s", e, err))
+			return 0, false
+		}
+		return res, true
+
+	case call:
+		bound := call{e.name, make([]Expr, len(e.args))}
+		ok := true
+		for i, a := range e.args {
+			x, okx := evalAllErrors(a, errs)
+			ok = ok && okx
+			bound.args[i] = num(x)
+		}
+		if !ok {
+			return 0, false
+		}
+		res, err := bound.Eval()
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("evaluation of %v failed: %s", e, err))
+			return 0, false
+		}
+		return res, true
+	}
+	res, err := e.Eval()
+	if err != nil {
+		*errs = append(*errs, err)
+		return 0, false
+	}
+	return res, true
+}
@@ -0,0 +1,33 @@
+package expr
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEvalAllErrors(t *testing.T) {
+	res, errs := EvalAllErrors(mustParse(t, "(1 / 0 + 2) * 3 - 4 / (2 - 2)"))
+	if !math.IsNaN(res) {
+		t.Errorf("result = %v, want NaN", res)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("got %d errors %v, want 2", len(errs), errs)
+	}
+	for i, want := range []string{"1.00 / 0.00", "4.00 / 2.00 - 2.00"} {
+		if !strings.Contains(errs[i].Error(), want) || !strings.Contains(errs[i].Error(), "division by zero") {
+			t.Errorf("error %d = %q, want division by zero in %s", i, errs[i], want)
+		}
+	}
+
+	// every argument of a call is evaluated
+	res, errs = EvalAllErrors(mustParse(t, "min(1 / 0, 2 / 0) + 1"))
+	if !math.IsNaN(res) || len(errs) != 2 {
+		t.Errorf("EvalAllErrors(min(1 / 0, 2 / 0) + 1) = %v, %v, want NaN with 2 errors", res, errs)
+	}
+
+	res, errs = EvalAllErrors(mustParse(t, "(1 + 2) * -3"))
+	if res != -9 || errs != nil {
+		t.Errorf("EvalAllErrors((1 + 2) * -3) = %v, %v, want -9 without errors", res, errs)
+	}
+}
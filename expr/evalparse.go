@@ -0,0 +1,217 @@
+package expr
+
+// The functions in this package can be used to parse an arthimetic expression in text form into an ast-like data structure.
+// We use a lexer and a parse algorithm for symbolic expressions; both ideas come from Donovan & Kernighan (2016)
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"text/scanner"
+)
+
+// EvalParse parses the content from the input reader as an arithmetic expression.
+// It uses an adaptation of a parse algorithm for symbolic expressions by D&K(2016)
+// In addition to its counterpart Parse(), it makes evaluation in place of parsed operands.
+// This way, the returned Expr is in fact a num.
+func EvalParse(r io.Reader, opts ...Option) (Expr, error) {
+	e, _, err := evalParse(r, opts)
+	return e, err
+}
+
+// evalParse evaluates the whole input in place and also returns the lexer, for the
+// number of symbols it scanned.
+func evalParse(r io.Reader, opts []Option) (Expr, *lexer, error) {
+	lex := newLexer(r, opts)
+	lex.next() // initial lookahead
+	if lex.token == scanner.EOF && lex.cfg.emptyAsZero {
+		return num(0), lex, nil
+	}
+	e, err := evalparseExpr(lex)
+	if err != nil {
+		return nil, lex, fmt.Errorf("could not parse %s: %s", lex, err)
+	}
+	if lex.token != scanner.EOF {
+		return nil, lex, fmt.Errorf("unexpected %s", lex)
+	}
+
+	return e, lex, nil
+}
+
+// maxKeptSource is the most source text EvalParseKeep holds on to. An expression short
+// enough to be echoed fits in it many times over.
+const maxKeptSource = 64 << 10
+
+// EvalParseKeep evaluates the input in place like EvalParse, and also returns an expression
+// for printing. If the input is short enough to be echoed by FprintResult, this is the
+// parsed tree of the input, which is parsed a second time for it. Otherwise it is just
+// the value, as a number, which is not worth echoing.
+func EvalParseKeep(r io.Reader, opts ...Option) (orig Expr, value float64, err error) {
+	src := &prefixBuffer{max: maxKeptSource}
+	e, lex, err := evalParse(io.TeeReader(r, src), opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	value, _ = e.Eval() // e is a number
+	if lex.symbols <= MaxEchoLen && !src.truncated {
+		if orig, err := Parse(strings.NewReader(src.String()), opts...); err == nil {
+			return orig, value, nil
+		}
+	}
+	return num(value), value, nil
+}
+
+// prefixBuffer keeps the first max bytes written to it and discards the rest.
+type prefixBuffer struct {
+	strings.Builder
+	max       int
+	truncated bool
+}
+
+func (b *prefixBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); len(p) > room {
+		b.Builder.Write(p[:room])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.Builder.Write(p)
+}
+
+func evalparseExpr(lex *lexer) (Expr, error) { return evalparseBinary(lex, 1) }
+
+// eval evaluates a partial result. Errors are ignored, as if the result were 0,
+// unless the option WithStrictEval is set. With WithFiniteEval, a result that is not
+// finite is an error.
+func (lex *lexer) eval(e Expr) (float64, error) {
+	v, err := e.Eval()
+	if err != nil && lex.cfg.strictEval {
+		return 0, err
+	}
+	if lex.cfg.finiteEval && (math.IsInf(v, 0) || math.IsNaN(v)) {
+		return 0, fmt.Errorf("%s is not finite", Canonical(e))
+	}
+	return v, nil
+}
+
+// evalparseBinary stops when it encounters an
+// operator of lower prio than prio0.
+func evalparseBinary(lex *lexer, prio0 int) (Expr, error) {
+	left, err := evalparseUnary(lex)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse expression in unary %s: %s", lex, err)
+	}
+	for prio := priority(lex.token); prio >= prio0; prio-- {
+		for priority(lex.token) == prio {
+			op := lex.token
+			lex.next() // consume operator
+			right, err := evalparseBinary(lex, prio+1)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse expression in unary %s: %s", lex, err)
+			}
+			leftEval, err := lex.eval(left)
+			if err != nil {
+				return nil, err
+			}
+			left = binary{op, num(leftEval), right}
+			// left = binary{op, left, right}
+		}
+	}
+	leftEval, err := lex.eval(left)
+	if err != nil {
+		return nil, err
+	}
+	return num(leftEval), nil
+}
+
+func evalparseUnary(lex *lexer) (Expr, error) {
+	if isPrefix(lex.token) {
+		op := lex.token
+		lex.next() // consume '+', '-' or prefix operator
+		e, err := evalparseUnary(lex)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse expression in unary %s: %s", lex, err)
+		}
+		eEval, err := lex.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		return unary{op, num(eEval)}, nil
+		// return unary{op, e}, nil
+	}
+	return evalparsePower(lex)
+}
+
+// evalparsePower follows parsePower, evaluating the power right away.
+func evalparsePower(lex *lexer) (Expr, error) {
+	base, err := evalparsePrimary(lex)
+	if err != nil || lex.token != '^' {
+		return base, err
+	}
+	lex.next() // consume '^'
+	exp, err := evalparseUnary(lex)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse exponent %s: %s", lex, err)
+	}
+	v, err := lex.eval(binary{'^', base, exp})
+	if err != nil {
+		return nil, err
+	}
+	return num(v), nil
+}
+
+func evalparsePrimary(lex *lexer) (Expr, error) {
+	switch lex.token {
+	case scanner.Int, scanner.Float:
+		text, err := lex.numberText()
+		if err != nil {
+			return nil, err
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the float number %s: %s", lex, err)
+		}
+		lex.next() // consume number
+		return num(f), nil
+
+	case '(':
+		if err := lex.openParen(); err != nil {
+			return nil, err
+		}
+		lex.next() // consume '('
+		e, err := evalparseExpr(lex)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the symbol %s: %s", lex, err)
+		}
+		eEval, err := lex.eval(e)
+		if err != nil {
+			return nil, err
+		}
+		if lex.token != ')' {
+			return nil, fmt.Errorf("got %s, want ')'", lex)
+		}
+		lex.closeParen()
+		lex.next() // consume ')'
+		return num(eEval), nil
+
+	case scanner.Ident:
+		if c, ok := constants[lex.text()]; ok {
+			lex.next() // consume name
+			return num(c), nil
+		}
+		if _, ok := functions[lex.text()]; !ok {
+			return nil, fmt.Errorf("cannot evaluate %s while parsing, variables need Parse and EvalEnv", lex)
+		}
+		c, err := parseCall(lex, evalparseExpr, evalparseUnary)
+		if err != nil {
+			return nil, err
+		}
+		v, err := lex.eval(c)
+		if err != nil {
+			return nil, err
+		}
+		return num(v), nil
+	}
+	return nil, fmt.Errorf("unexpected %s", lex)
+}
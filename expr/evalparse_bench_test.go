@@ -1,4 +1,4 @@
-package main
+package expr
 
 import (
 	"bytes"
@@ -24,11 +24,11 @@ func benchmarkParseAndEval(fileName string, b *testing.B) {
 }
 
 // Series of benchmark functions for each file size.
-func BenchmarkParseAndEval_1k(b *testing.B)   { benchmarkParseAndEval("./testdata/1k.txt", b) }
-func BenchmarkParseAndEval_10k(b *testing.B)  { benchmarkParseAndEval("./testdata/10k.txt", b) }
-func BenchmarkParseAndEval_100k(b *testing.B) { benchmarkParseAndEval("./testdata/100k.txt", b) }
-func BenchmarkParseAndEval_1m(b *testing.B)   { benchmarkParseAndEval("./testdata/1m.txt", b) }
-func BenchmarkParseAndEval_10m(b *testing.B)  { benchmarkParseAndEval("./testdata/10m.txt", b) }
+func BenchmarkParseAndEval_1k(b *testing.B)   { benchmarkParseAndEval("../testdata/1k.txt", b) }
+func BenchmarkParseAndEval_10k(b *testing.B)  { benchmarkParseAndEval("../testdata/10k.txt", b) }
+func BenchmarkParseAndEval_100k(b *testing.B) { benchmarkParseAndEval("../testdata/100k.txt", b) }
+func BenchmarkParseAndEval_1m(b *testing.B)   { benchmarkParseAndEval("../testdata/1m.txt", b) }
+func BenchmarkParseAndEval_10m(b *testing.B)  { benchmarkParseAndEval("../testdata/10m.txt", b) }
 
 func benchmarkEvalParseAndEval(fileName string, b *testing.B) {
 	// Read the entire file content into memory
@@ -48,10 +48,10 @@ func benchmarkEvalParseAndEval(fileName string, b *testing.B) {
 }
 
 // Series of benchmark functions for each file size.
-func BenchmarkEvalParseAndEval_1k(b *testing.B)  { benchmarkEvalParseAndEval("./testdata/1k.txt", b) }
-func BenchmarkEvalParseAndEval_10k(b *testing.B) { benchmarkEvalParseAndEval("./testdata/10k.txt", b) }
+func BenchmarkEvalParseAndEval_1k(b *testing.B)  { benchmarkEvalParseAndEval("../testdata/1k.txt", b) }
+func BenchmarkEvalParseAndEval_10k(b *testing.B) { benchmarkEvalParseAndEval("../testdata/10k.txt", b) }
 func BenchmarkEvalParseAndEval_100k(b *testing.B) {
-	benchmarkEvalParseAndEval("./testdata/100k.txt", b)
+	benchmarkEvalParseAndEval("../testdata/100k.txt", b)
 }
-func BenchmarkEvalParseAndEval_1m(b *testing.B)  { benchmarkEvalParseAndEval("./testdata/1m.txt", b) }
-func BenchmarkEvalParseAndEval_10m(b *testing.B) { benchmarkEvalParseAndEval("./testdata/10m.txt", b) }
+func BenchmarkEvalParseAndEval_1m(b *testing.B)  { benchmarkEvalParseAndEval("../testdata/1m.txt", b) }
+func BenchmarkEvalParseAndEval_10m(b *testing.B) { benchmarkEvalParseAndEval("../testdata/10m.txt", b) }
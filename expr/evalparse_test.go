@@ -0,0 +1,35 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvalParseKeep(t *testing.T) {
+	orig, value, err := EvalParseKeep(strings.NewReader("-(1 + 2) * 3"))
+	if err != nil {
+		t.Fatalf("EvalParseKeep failed: %v", err)
+	}
+	if value != -9 {
+		t.Errorf("value = %v, want -9", value)
+	}
+	if got := orig.String(); got != "-1.00 + 2.00 * 3.00" {
+		t.Errorf("kept expression = %s, want the parsed tree", got)
+	}
+
+	// a long expression is not kept, only its value
+	long := strings.Repeat("1 + ", 2000) + "1"
+	orig, value, err = EvalParseKeep(strings.NewReader(long))
+	if err != nil {
+		t.Fatalf("EvalParseKeep failed: %v", err)
+	}
+	if value != 2001 {
+		t.Errorf("value = %v, want 2001", value)
+	}
+	if !Same(orig, num(2001)) || !IsConstant(orig) {
+		t.Errorf("kept long expression = %v, want the number 2001", orig)
+	}
+	if _, err := MarshalBinary(orig); err != nil {
+		t.Errorf("MarshalBinary of the kept long expression failed: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// An Evaluator computes the value of an expression with some arithmetic backend, so that
+// the backend can be chosen at run time. Backends with exact, decimal or integer results
+// return them rounded to the nearest float64.
+type Evaluator interface {
+	Eval(e Expr) (float64, error)
+}
+
+// EvaluatorFunc adapts an evaluation function to the Evaluator interface.
+type EvaluatorFunc func(Expr) (float64, error)
+
+func (f EvaluatorFunc) Eval(e Expr) (float64, error) {
+	return f(e)
+}
+
+var (
+	// Float64Evaluator evaluates in float64, like Expr.Eval.
+	Float64Evaluator Evaluator = EvaluatorFunc(func(e Expr) (float64, error) {
+		return e.Eval()
+	})
+
+	// Float32Evaluator evaluates with EvalFloat32.
+	Float32Evaluator Evaluator = EvaluatorFunc(func(e Expr) (float64, error) {
+		f, err := EvalFloat32(e)
+		return float64(f), err
+	})
+
+	// ExactEvaluator evaluates with EvalRat, so the result is only rounded once, at the end.
+	ExactEvaluator Evaluator = EvaluatorFunc(func(e Expr) (float64, error) {
+		r, err := EvalRat(e)
+		if err != nil {
+			return 0, err
+		}
+		f, _ := r.Float64()
+		return f, nil
+	})
+
+	// BigIntEvaluator evaluates with EvalBigInt.
+	BigIntEvaluator Evaluator = EvaluatorFunc(func(e Expr) (float64, error) {
+		i, err := EvalBigInt(e)
+		if err != nil {
+			return 0, err
+		}
+		f, _ := i.Float64()
+		return f, nil
+	})
+
+	// SaturatingEvaluator evaluates with EvalSaturatingInt.
+	SaturatingEvaluator Evaluator = EvaluatorFunc(func(e Expr) (float64, error) {
+		i, err := EvalSaturatingInt(e)
+		return float64(i), err
+	})
+)
+
+// ModEvaluator returns an Evaluator using EvalMod with the modulus m.
+func ModEvaluator(m int64) Evaluator {
+	return EvaluatorFunc(func(e Expr) (float64, error) {
+		i, err := EvalMod(e, m)
+		return float64(i), err
+	})
+}
+
+// WrapEvaluator returns an Evaluator using EvalWrap with integers of the given bits.
+func WrapEvaluator(bits int) Evaluator {
+	return EvaluatorFunc(func(e Expr) (float64, error) {
+		i, err := EvalWrap(e, bits)
+		return float64(i), err
+	})
+}
+
+// DecimalEvaluator returns an Evaluator using EvalDecimalRounding with the given scale and rounding.
+func DecimalEvaluator(scale int, mode Rounding) Evaluator {
+	return EvaluatorFunc(func(e Expr) (float64, error) {
+		s, err := EvalDecimalRounding(e, scale, mode)
+		if err != nil {
+			return 0, err
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("decimal result %s out of range: %s", s, err)
+		}
+		return f, nil
+	})
+}
@@ -0,0 +1,38 @@
+package expr
+
+import "testing"
+
+func TestEvaluators(t *testing.T) {
+	e := mustParse(t, "0.1 + 0.2 - 0.3")
+	tests := []struct {
+		name string
+		ev   Evaluator
+		want float64
+	}{
+		{"float64", Float64Evaluator, 5.551115123125783e-17},
+		{"exact", ExactEvaluator, 0},
+		{"decimal", DecimalEvaluator(1, RoundHalfEven), 0},
+	}
+	for _, test := range tests {
+		got, err := test.ev.Eval(e)
+		if err != nil {
+			t.Fatalf("%s evaluator failed: %v", test.name, err)
+		}
+		if got != test.want {
+			t.Errorf("%s evaluator = %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	ints := mustParse(t, "7 * 5 - 2")
+	for _, ev := range []Evaluator{Float64Evaluator, BigIntEvaluator, SaturatingEvaluator, WrapEvaluator(8)} {
+		if got, err := ev.Eval(ints); err != nil || got != 33 {
+			t.Errorf("evaluation of %v = %v, %v, want 33", ints, got, err)
+		}
+	}
+	if got, _ := ModEvaluator(10).Eval(ints); got != 3 {
+		t.Errorf("evaluation of %v modulo 10 = %v, want 3", ints, got)
+	}
+	if _, err := BigIntEvaluator.Eval(e); err == nil {
+		t.Error("integer evaluation of decimals succeeded, want error")
+	}
+}
@@ -0,0 +1,116 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+)
+
+// A DAG is the computation of an expression as a directed acyclic graph. Identical
+// subexpressions are computed once and appear as a single node used by several others.
+type DAG struct {
+	Nodes []DAGNode // in evaluation order: the inputs of a node come before it
+	Root  int       // index of the node holding the result
+}
+
+// A DAGNode is one step of the computation.
+type DAGNode struct {
+	Op     rune    // operator of the step, 0 for a number or a call
+	Inputs []int   // indices of the operands or arguments in DAG.Nodes, in order
+	Value  float64 // value computed by the step
+	Func   string  // name of the function of a call, empty otherwise
+}
+
+// Explain evaluates the expression and returns the graph of its computation, e.g. for
+// showing how a result was obtained. Subexpressions with the same operators and numbers
+// are interned: (1 + 2) * (1 + 2) has a single node for 1 + 2, used twice by the '*'.
+func Explain(e Expr) (*DAG, error) {
+	d := &DAG{}
+	seen := make(map[dagKey]int)
+	root, err := d.add(e, seen)
+	if err != nil {
+		return nil, err
+	}
+	d.Root = root
+	return d, nil
+}
+
+// dagKey identifies a node by its operator and inputs, or by the bits of its value for numbers.
+// A call is identified by its function and the list of its inputs.
+type dagKey struct {
+	op    rune
+	x, y  int
+	value uint64
+	fn    string
+	args  string
+}
+
+// add appends the nodes computing e that are not there yet and returns the index of e.
+func (d *DAG) add(e Expr, seen map[dagKey]int) (int, error) {
+	var key dagKey
+	var value float64
+	var inputs []int // of a call, the operands are in the key
+	switch e := e.(type) {
+	case num, literal, constant:
+		value, _ = e.Eval()
+		key = dagKey{0, -1, -1, math.Float64bits(value), "", ""}
+
+	case unary:
+		x, err := d.add(e.x, seen)
+		if err != nil {
+			return 0, err
+		}
+		key = dagKey{e.op, x, -1, 0, "", ""}
+		if value, err = (unary{e.op, num(d.Nodes[x].Value)}).Eval(); err != nil {
+			return 0, err
+		}
+
+	case binary:
+		x, err := d.add(e.x, seen)
+		if err != nil {
+			return 0, err
+		}
+		y, err := d.add(e.y, seen)
+		if err != nil {
+			return 0, err
+		}
+		key = dagKey{e.op, x, y, 0, "", ""}
+		if value, err = (binary{e.op, num(d.Nodes[x].Value), num(d.Nodes[y].Value)}).Eval(); err != nil {
+			return 0, fmt.Errorf("evaluation of %v failed: %s", e, err)
+		}
+
+	case call:
+		bound := call{e.name, make([]Expr, len(e.args))}
+		for i, a := range e.args {
+			x, err := d.add(a, seen)
+			if err != nil {
+				return 0, err
+			}
+			inputs = append(inputs, x)
+			bound.args[i] = num(d.Nodes[x].Value)
+		}
+		key = dagKey{0, -1, -1, 0, e.name, fmt.Sprint(inputs)}
+		var err error
+		if value, err = bound.Eval(); err != nil {
+			return 0, fmt.Errorf("evaluation of %v failed: %s", e, err)
+		}
+
+	case variable:
+		return 0, fmt.Errorf("cannot explain the undefined variable %s", string(e))
+
+	default:
+		return 0, fmt.Errorf("cannot explain %v", e)
+	}
+
+	if i, ok := seen[key]; ok {
+		return i, nil
+	}
+	node := DAGNode{Op: key.op, Func: key.fn, Inputs: inputs, Value: value}
+	for _, in := range []int{key.x, key.y} {
+		if in >= 0 {
+			node.Inputs = append(node.Inputs, in)
+		}
+	}
+	d.Nodes = append(d.Nodes, node)
+	seen[key] = len(d.Nodes) - 1
+	return len(d.Nodes) - 1, nil
+}
@@ -0,0 +1,52 @@
+package expr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	d, err := Explain(mustParse(t, "(1 + 2) * (1 + 2) - 1"))
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	want := &DAG{
+		Nodes: []DAGNode{
+			{0, nil, 1, ""},
+			{0, nil, 2, ""},
+			{'+', []int{0, 1}, 3, ""},
+			{'*', []int{2, 2}, 9, ""}, // the repeated 1 + 2 is computed once
+			{'-', []int{3, 0}, 8, ""}, // and so is the number 1
+		},
+		Root: 4,
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("Explain = %+v, want %+v", d, want)
+	}
+
+	if _, err := Explain(mustParse(t, "2 / (1 - 1)")); err == nil {
+		t.Error("Explain of a division by zero succeeded, want error")
+	}
+	if _, err := Explain(mustParse(t, "x + 1")); err == nil {
+		t.Error("Explain of a variable succeeded, want error")
+	}
+}
+
+func TestExplainCalls(t *testing.T) {
+	d, err := Explain(mustParse(t, "max(4, sqrt(4)) + sqrt(4)"))
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	want := &DAG{
+		Nodes: []DAGNode{
+			{0, nil, 4, ""},
+			{0, []int{0}, 2, "sqrt"},
+			{0, []int{0, 1}, 4, "max"},
+			{'+', []int{2, 1}, 6, ""}, // the repeated sqrt(4) is computed once
+		},
+		Root: 3,
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("Explain = %+v, want %+v", d, want)
+	}
+}
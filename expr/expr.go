@@ -0,0 +1,18 @@
+// Package expr parses arithmetic expressions into trees and evaluates them, in float64
+// arithmetic or one of the exact and integer evaluators. The calcast command is a thin
+// command line interface on top of it.
+package expr
+
+// An Expr is an arithmetic expression.
+type Expr interface {
+	// Eval returns the value of this Expr in the environment env.
+	Eval() (float64, error)
+	// Expr is a Stringer too
+	String() string
+	// Len returns the number of symbols of the expression. (A number is just one symbol.)
+	Len() int
+	// Cost returns the relative expense of evaluating the expression, for schedulers:
+	// each number costs 1, and each operation adds its weight: 1 for '+', '-' and the
+	// signs, 2 for '*', 4 for '/' and '%', and 8 for '^' and function calls.
+	Cost() int
+}
@@ -0,0 +1,15 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func mustParse(t *testing.T, s string) Expr {
+	t.Helper()
+	e, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("could not parse %q: %v", s, err)
+	}
+	return e
+}
@@ -0,0 +1,91 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// EvalFloat32 evaluates the expression in float32 precision throughout, rounding every
+// intermediate result to 32 bits like graphics and ML pipelines do. This differs from
+// evaluating in float64 and converting the result at the end.
+// Registered prefix operators and function calls are computed in float64 from their
+// float32 operands and rounded to float32.
+func EvalFloat32(e Expr) (float32, error) {
+	switch e := e.(type) {
+	case num:
+		return float32(e), nil
+
+	case constant:
+		v, _ := e.Eval()
+		return float32(v), nil
+
+	case literal:
+		// rounding the text directly avoids rounding twice, first to float64
+		if f, err := strconv.ParseFloat(e.text, 32); err == nil {
+			return float32(f), nil
+		}
+		return float32(e.num), nil
+
+	case unary:
+		x, err := EvalFloat32(e.x)
+		if err != nil {
+			return 0, err
+		}
+		switch e.op {
+		case '+':
+			return x, nil
+		case '-':
+			return -x, nil
+		}
+		if fn, ok := prefixOps[e.op]; ok {
+			r, err := fn(float64(x))
+			return float32(r), err
+		}
+		return 0, fmt.Errorf("unsupported unary operator: %q", e.op)
+
+	case binary:
+		x, err := EvalFloat32(e.x)
+		if err != nil {
+			return 0, err
+		}
+		y, err := EvalFloat32(e.y)
+		if err != nil {
+			return 0, err
+		}
+		switch e.op {
+		case '+':
+			return x + y, nil
+		case '-':
+			return x - y, nil
+		case '*':
+			return x * y, nil
+		case '/':
+			if y == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			return x / y, nil
+		case '%':
+			if y == 0 {
+				return 0, fmt.Errorf("modulo by zero")
+			}
+			return float32(math.Mod(float64(x), float64(y))), nil
+		case '^':
+			return float32(math.Pow(float64(x), float64(y))), nil
+		}
+		return 0, fmt.Errorf("unsupported binary operator: %q", e.op)
+
+	case call:
+		bound := call{e.name, make([]Expr, len(e.args))}
+		for i, a := range e.args {
+			x, err := EvalFloat32(a)
+			if err != nil {
+				return 0, err
+			}
+			bound.args[i] = num(x)
+		}
+		r, err := bound.Eval()
+		return float32(r), err
+	}
+	return 0, fmt.Errorf("cannot evaluate %v in float32", e)
+}
@@ -0,0 +1,43 @@
+package expr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvalFloat32(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float32
+	}{
+		{"1.5 * 4 - 2", 4},
+		{"-(1 / 4)", -0.25},
+		// 2^24 + 1 is not a float32, so each addition of 1 to 2^24 is lost;
+		// in float64 the result would be 2^24 + 2 and round to 16777218 instead
+		{"16777216 + 1 + 1", 16777216},
+		{"sqrt(2)", float32(math.Sqrt2)},
+		{"max(16777216 + 1, 1) + 1", 16777216}, // the argument is rounded to float32 too
+	}
+	for _, test := range tests {
+		got, err := EvalFloat32(mustParse(t, test.input))
+		if err != nil {
+			t.Fatalf("EvalFloat32(%q) failed: %v", test.input, err)
+		}
+		if got != test.want {
+			t.Errorf("EvalFloat32(%q) = %v, want %v", test.input, got, test.want)
+		}
+	}
+
+	e := mustParse(t, "16777216 + 1 + 1")
+	f64, _ := e.Eval()
+	if f32, _ := EvalFloat32(e); float32(f64) == f32 {
+		t.Errorf("float32 and float64 evaluation of %v agree on %v, want them to diverge", e, f32)
+	}
+
+	if _, err := EvalFloat32(mustParse(t, "1 / (2 - 2)")); err == nil {
+		t.Error("EvalFloat32 of a division by zero succeeded, want error")
+	}
+	if _, err := EvalFloat32(mustParse(t, "sqrt(1 / 0)")); err == nil {
+		t.Error("EvalFloat32 of a division by zero in an argument succeeded, want error")
+	}
+}
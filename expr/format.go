@@ -0,0 +1,101 @@
+package expr
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// A ResultFormat describes how results are displayed to humans.
+// Very large and very small results switch to scientific notation, like on a calculator.
+type ResultFormat struct {
+	Precision int     // number of digits after the decimal point
+	SciAbove  float64 // use scientific notation when |x| >= SciAbove; 0 disables it
+	SciBelow  float64 // use scientific notation when 0 < |x| < SciBelow; 0 disables it
+	Separator rune    // groups the thousands, like ' ' in 1 000 000.00; 0 means ','
+	Point     rune    // separates the fraction, like ',' in 1.000,50; 0 means '.'
+}
+
+// DefaultFormat is the format used by FormatResult.
+var DefaultFormat = ResultFormat{Precision: 2, SciAbove: 1e15, SciBelow: 1e-4}
+
+// FormatResult formats a result for display with the DefaultFormat.
+func FormatResult(x float64) string {
+	return DefaultFormat.Format(x)
+}
+
+// Format formats x in fixed-point notation with thousands separators,
+// or in scientific notation if x is outside the thresholds of the format.
+func (f ResultFormat) Format(x float64) string {
+	if math.IsInf(x, 0) || math.IsNaN(x) {
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	}
+	ax := math.Abs(x)
+	if (f.SciAbove > 0 && ax >= f.SciAbove) || (f.SciBelow > 0 && ax > 0 && ax < f.SciBelow) {
+		return f.punctuate(strconv.FormatFloat(x, 'e', f.Precision, 64))
+	}
+
+	// we use a new (English) printer for outputting thousands comma
+	p := message.NewPrinter(language.English)
+	return f.punctuate(p.Sprintf("%.*f", f.Precision, x))
+}
+
+// punctuate replaces the English separator and decimal point of s by those of the format.
+// Both are replaced in one pass, so that they can also be swapped.
+func (f ResultFormat) punctuate(s string) string {
+	if (f.Separator == 0 || f.Separator == ',') && (f.Point == 0 || f.Point == '.') {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ',' && f.Separator != 0:
+			return f.Separator
+		case r == '.' && f.Point != 0:
+			return f.Point
+		}
+		return r
+	}, s)
+}
+
+// FormatCanonical formats x for machines rather than humans: the shortest decimal that
+// parses back to exactly x, without thousands separators and independent of any locale.
+// Infinities and NaN come out as +Inf, -Inf and NaN.
+func FormatCanonical(x float64) string {
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+// MaxEchoLen is the length of the longest expression that is echoed with its result.
+const MaxEchoLen = 1000
+
+// FprintResult writes the result of the evaluation of exp to w. The expression is
+// echoed only if it is short enough (up to MaxEchoLen symbols) to be worth reading.
+// The result of a rounding function like round(2.5) is printed as an integer, 3.
+func FprintResult(w io.Writer, exp Expr, res float64) {
+	f := DefaultFormat
+	if isRounding(exp) {
+		f.Precision = 0
+	}
+	if exp.Len() <= MaxEchoLen {
+		fmt.Fprintf(w, "Eval(%v) = %s\n", exp, f.Format(res))
+	} else {
+		fmt.Fprintf(w, "Eval() = %s\n", f.Format(res))
+	}
+}
+
+// isRounding reports whether e is a call of a function whose results are integers.
+func isRounding(e Expr) bool {
+	c, ok := e.(call)
+	if !ok {
+		return false
+	}
+	switch c.name {
+	case "floor", "ceil", "round", "roundeven", "trunc":
+		return true
+	}
+	return false
+}
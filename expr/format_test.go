@@ -0,0 +1,126 @@
+package expr
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFormatResult(t *testing.T) {
+	tests := []struct {
+		x    float64
+		want string
+	}{
+		{0, "0.00"},
+		{1234567.891, "1,234,567.89"},
+		{-2.5, "-2.50"},
+		{999999999999999, "999,999,999,999,999.00"},
+		{1e15, "1.00e+15"},
+		{-1e15, "-1.00e+15"},
+		{6.780466519056739e36, "6.78e+36"},
+		{0.0001, "0.00"},
+		{0.0000999, "9.99e-05"},
+		{-0.0000999, "-9.99e-05"},
+		{math.Inf(1), "+Inf"},
+		{math.NaN(), "NaN"},
+	}
+	for _, test := range tests {
+		if got := FormatResult(test.x); got != test.want {
+			t.Errorf("FormatResult(%g) = %q, want %q", test.x, got, test.want)
+		}
+	}
+}
+
+func TestResultFormatThresholds(t *testing.T) {
+	f := ResultFormat{Precision: 3, SciAbove: 1000, SciBelow: 0.01}
+	tests := []struct {
+		x    float64
+		want string
+	}{
+		{999.9994, "999.999"},
+		{1000, "1.000e+03"},
+		{0.01, "0.010"},
+		{0.00999, "9.990e-03"},
+	}
+	for _, test := range tests {
+		if got := f.Format(test.x); got != test.want {
+			t.Errorf("Format(%g) = %q, want %q", test.x, got, test.want)
+		}
+	}
+
+	never := ResultFormat{Precision: 1}
+	if got := never.Format(1e20); got != "100,000,000,000,000,000,000.0" {
+		t.Errorf("Format without thresholds = %q, want fixed-point notation", got)
+	}
+}
+
+func TestFormatCanonical(t *testing.T) {
+	tests := []float64{
+		0.1, 0.1 + 0.2, 1.0 / 3, -2.5, 0, math.Copysign(0, -1),
+		1e21, 1e-7, 123456789.123, math.MaxFloat64, math.SmallestNonzeroFloat64,
+		9007199254740993, // rounds to 2^53
+	}
+	for _, x := range tests {
+		s := FormatCanonical(x)
+		if strings.Contains(s, ",") {
+			t.Errorf("FormatCanonical(%v) = %s, want no separators", x, s)
+		}
+		got, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.Float64bits(got) != math.Float64bits(x) {
+			t.Errorf("FormatCanonical(%v) = %s, parses back to %v, %v", x, s, got, err)
+		}
+	}
+}
+
+func TestResultFormatSeparators(t *testing.T) {
+	tests := []struct {
+		f    ResultFormat
+		x    float64
+		want string
+	}{
+		{ResultFormat{Precision: 2, Separator: ' '}, 1234567.891, "1 234 567.89"},
+		{ResultFormat{Precision: 2, Separator: '.', Point: ','}, -1234567.891, "-1.234.567,89"},
+		{ResultFormat{Precision: 1, Separator: '\'', Point: ','}, 999.95, "1'000,0"},
+		{ResultFormat{Precision: 2, SciAbove: 1e6, Point: ','}, 1234567.891, "1,23e+06"},
+		{ResultFormat{Precision: 2, Separator: ' '}, math.Inf(-1), "-Inf"},
+	}
+	for _, test := range tests {
+		if got := test.f.Format(test.x); got != test.want {
+			t.Errorf("%+v.Format(%g) = %q, want %q", test.f, test.x, got, test.want)
+		}
+	}
+}
+
+func TestFprintResult(t *testing.T) {
+	var buf bytes.Buffer
+	FprintResult(&buf, mustParse(t, "1000 * 1000 + 0.5"), 1000000.5)
+	if want := "Eval(1000.00 * 1000.00 + 0.50) = 1,000,000.50\n"; buf.String() != want {
+		t.Errorf("FprintResult wrote %q, want %q", buf.String(), want)
+	}
+
+	// long expressions are not echoed
+	buf.Reset()
+	long := mustParse(t, strings.Repeat("1 + ", 1000)+"1")
+	FprintResult(&buf, long, 1001)
+	if want := "Eval() = 1,001.00\n"; buf.String() != want {
+		t.Errorf("FprintResult wrote %q, want %q", buf.String(), want)
+	}
+
+	// the result of a rounding function is an integer and printed as one
+	for _, test := range []struct{ input, want string }{
+		{"round(2.5)", "Eval(round(2.50)) = 3\n"},
+		{"roundeven(2.5)", "Eval(roundeven(2.50)) = 2\n"},
+		{"floor(1234.5)", "Eval(floor(1234.50)) = 1,234\n"},
+		{"round(2.5) + 0.5", "Eval(round(2.50) + 0.50) = 3.50\n"},
+	} {
+		buf.Reset()
+		e := mustParse(t, test.input)
+		res, _ := e.Eval()
+		FprintResult(&buf, e, res)
+		if buf.String() != test.want {
+			t.Errorf("FprintResult of %q wrote %q, want %q", test.input, buf.String(), test.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EvalHooked evaluates the expression like Eval, and calls hook after every binary
+// operation with the operator, its operands and its result, in evaluation order.
+// The operations in the arguments of function calls are reported too, the calls are not.
+// It is meant for instrumentation and logging; Eval itself is not affected by it.
+func EvalHooked(e Expr, hook func(op rune, x, y, result float64)) (float64, error) {
+	return evalHooked(e, hook, nil)
+}
+
+// evalHooked is EvalHooked, additionally calling callHook, if not nil, after every call.
+func evalHooked(e Expr, hook func(op rune, x, y, result float64), callHook func(c call, args []float64, result float64)) (float64, error) {
+	switch e := e.(type) {
+	case unary:
+		x, err := evalHooked(e.x, hook, callHook)
+		if err != nil {
+			return 0, err
+		}
+		return unary{e.op, num(x)}.Eval()
+
+	case binary:
+		x, err := evalHooked(e.x, hook, callHook)
+		if err != nil {
+			return 0, err
+		}
+		y, err := evalHooked(e.y, hook, callHook)
+		if err != nil {
+			return 0, err
+		}
+		res, err := binary{e.op, num(x), num(y)}.Eval()
+		if err != nil {
+			return 0, fmt.Errorf("evaluation of %v failed: %s", e, err)
+		}
+		hook(e.op, x, y, res)
+		return res, nil
+
+	case call:
+		args := make([]float64, len(e.args))
+		bound := call{e.name, make([]Expr, len(e.args))}
+		for i, a := range e.args {
+			x, err := evalHooked(a, hook, callHook)
+			if err != nil {
+				return 0, err
+			}
+			args[i], bound.args[i] = x, num(x)
+		}
+		res, err := bound.Eval()
+		if err != nil {
+			return 0, err
+		}
+		if callHook != nil {
+			callHook(e, args, res)
+		}
+		return res, nil
+	}
+	return e.Eval()
+}
+
+// formatCall writes a call with the values of its arguments, like exp(1000).
+func formatCall(name string, args []float64) string {
+	s := make([]string, len(args))
+	for i, x := range args {
+		s[i] = fmt.Sprintf("%g", x)
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(s, ", "))
+}
@@ -0,0 +1,69 @@
+package expr
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestEvalHooked(t *testing.T) {
+	var ops []string
+	hook := func(op rune, x, y, result float64) {
+		ops = append(ops, fmt.Sprintf("%g %c %g = %g", x, op, y, result))
+	}
+
+	got, err := EvalHooked(mustParse(t, "1 + 2 * -3 - 4 / 2"), hook)
+	if err != nil {
+		t.Fatalf("EvalHooked failed: %v", err)
+	}
+	if got != -7 {
+		t.Errorf("EvalHooked = %g, want -7", got)
+	}
+	want := []string{"2 * -3 = -6", "1 + -6 = -5", "4 / 2 = 2", "-5 - 2 = -7"}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("hook saw %q, want %q", ops, want)
+	}
+
+	// operations in the arguments of calls are seen too
+	ops = nil
+	if got, err := EvalHooked(mustParse(t, "2 * sqrt(1 + 3)"), hook); err != nil || got != 4 {
+		t.Errorf("EvalHooked with a call = %v, %v, want 4", got, err)
+	}
+	want = []string{"1 + 3 = 4", "2 * 2 = 4"}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("hook saw %q, want %q", ops, want)
+	}
+}
+
+func TestEvalHookedSeesEveryOperation(t *testing.T) {
+	f, err := os.Open("../testdata/10k.txt")
+	if err != nil {
+		t.Fatalf("could not open file: %v", err)
+	}
+	defer f.Close()
+	e, err := Parse(f)
+	if err != nil {
+		t.Fatalf("could not parse expression: %v", err)
+	}
+
+	calls := 0
+	got, err := EvalHooked(e, func(rune, float64, float64, float64) { calls++ })
+	if err != nil {
+		t.Fatalf("EvalHooked failed: %v", err)
+	}
+	want, _ := e.Eval()
+	if got != want {
+		t.Errorf("EvalHooked = %g, want %g as Eval", got, want)
+	}
+	binaries := 0
+	Walk(e, func(e Expr) bool {
+		if _, ok := e.(binary); ok {
+			binaries++
+		}
+		return true
+	})
+	if calls != binaries {
+		t.Errorf("hook called %d times, want once per binary operation (%d)", calls, binaries)
+	}
+}
@@ -0,0 +1,120 @@
+package expr
+
+import "fmt"
+
+// A Kind classifies the nodes of an expression tree.
+type Kind int
+
+const (
+	NumberKind   Kind = iota // a number
+	SignKind                 // a unary '+' or '-'
+	PrefixKind               // a unary operator registered with RegisterPrefix
+	AddKind                  // a binary '+'
+	SubtractKind             // a binary '-'
+	MultiplyKind             // a binary '*'
+	DivideKind               // a binary '/'
+	ModuloKind               // a binary '%'
+	PowerKind                // a binary '^'
+	CallKind                 // a function call
+	VariableKind             // a variable
+	OtherKind                // any node not built by Parse
+)
+
+func (k Kind) String() string {
+	switch k {
+	case NumberKind:
+		return "number"
+	case SignKind:
+		return "sign"
+	case PrefixKind:
+		return "prefix"
+	case AddKind:
+		return "addition"
+	case SubtractKind:
+		return "subtraction"
+	case MultiplyKind:
+		return "multiplication"
+	case DivideKind:
+		return "division"
+	case ModuloKind:
+		return "modulo"
+	case PowerKind:
+		return "power"
+	case CallKind:
+		return "call"
+	case VariableKind:
+		return "variable"
+	}
+	return "other"
+}
+
+// KindOf returns the kind of the root node of the expression.
+func KindOf(e Expr) Kind {
+	switch e := e.(type) {
+	case num, literal, constant:
+		return NumberKind
+	case unary:
+		if e.op != '+' && e.op != '-' {
+			return PrefixKind
+		}
+		return SignKind
+	case call:
+		return CallKind
+	case variable:
+		return VariableKind
+	case binary:
+		switch e.op {
+		case '+':
+			return AddKind
+		case '-':
+			return SubtractKind
+		case '*':
+			return MultiplyKind
+		case '/':
+			return DivideKind
+		case '%':
+			return ModuloKind
+		case '^':
+			return PowerKind
+		}
+	}
+	return OtherKind
+}
+
+// DisallowKinds returns an error naming the first node of one of the given kinds found
+// in the expression, or nil if there is none. It restricts what an already built tree
+// may contain, e.g. no divisions, independently of how the tree was built.
+func DisallowKinds(e Expr, kinds ...Kind) error {
+	disallowed := make(map[Kind]bool)
+	for _, k := range kinds {
+		disallowed[k] = true
+	}
+
+	var err error
+	Walk(e, func(e Expr) bool {
+		if err != nil {
+			return false
+		}
+		if k := KindOf(e); disallowed[k] {
+			err = fmt.Errorf("%s not allowed: %v", k, e)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// IsConstant reports whether the expression is built only from numbers and operations,
+// so that it can be evaluated without binding anything first. Variables and placeholders
+// like the ?x wildcards of rewrite rules make an expression non-constant.
+func IsConstant(e Expr) bool {
+	free := true
+	Walk(e, func(e Expr) bool {
+		switch e.(type) {
+		case num, literal, constant, unary, binary, call:
+		default:
+			free = false
+		}
+		return free
+	})
+	return free
+}
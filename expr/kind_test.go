@@ -0,0 +1,53 @@
+package expr
+
+import "testing"
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		e    Expr
+		want Kind
+	}{
+		{num(1), NumberKind},
+		{unary{'-', num(1)}, SignKind},
+		{unary{'√', num(1)}, PrefixKind},
+		{binary{'+', num(1), num(2)}, AddKind},
+		{binary{'-', num(1), num(2)}, SubtractKind},
+		{binary{'*', num(1), num(2)}, MultiplyKind},
+		{binary{'/', num(1), num(2)}, DivideKind},
+		{panicky{}, OtherKind},
+	}
+	for _, test := range tests {
+		if got := KindOf(test.e); got != test.want {
+			t.Errorf("KindOf(%v) = %s, want %s", test.e, got, test.want)
+		}
+	}
+}
+
+func TestDisallowKinds(t *testing.T) {
+	e := mustParse(t, "1 + 2 * (3 / -4)")
+	if err := DisallowKinds(e, DivideKind); err == nil {
+		t.Error("DisallowKinds accepted a tree with a division")
+	}
+	if err := DisallowKinds(e, SignKind, AddKind); err == nil {
+		t.Error("DisallowKinds accepted a tree with an addition")
+	}
+	if err := DisallowKinds(e, SubtractKind, OtherKind); err != nil {
+		t.Errorf("DisallowKinds rejected a tree without subtractions: %v", err)
+	}
+	if err := DisallowKinds(e); err != nil {
+		t.Errorf("DisallowKinds without kinds rejected the tree: %v", err)
+	}
+}
+
+func TestIsConstant(t *testing.T) {
+	if !IsConstant(mustParse(t, "2 + 3 * -(4 / 5)")) {
+		t.Error("IsConstant(2 + 3 * -(4 / 5)) = false, want true")
+	}
+	pattern, err := parsePattern("?x + 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsConstant(pattern) {
+		t.Error("IsConstant(?x + 3) = true, want false")
+	}
+}
@@ -0,0 +1,61 @@
+package expr
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// ParseMany parses named expressions, one per line in the form label: expression, like
+//
+//	total: 2 + 3
+//	half: 7 / 2
+//
+// and returns them by label, so that a configuration file can name its formulas.
+// Labels are identifiers of letters, digits and underscores not starting with a digit,
+// and each label may occur only once. Blank lines are skipped.
+func ParseMany(r io.Reader, opts ...Option) (map[string]Expr, error) {
+	exprs := make(map[string]Expr)
+	br := bufio.NewReader(r) // not a bufio.Scanner, which limits the line length
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		if strings.TrimSpace(line) != "" {
+			label, src, ok := strings.Cut(line, ":")
+			if !ok {
+				return nil, fmt.Errorf("line %d: missing label, want label: expression", n)
+			}
+			label = strings.TrimSpace(label)
+			if !isIdentifier(label) {
+				return nil, fmt.Errorf("line %d: invalid label %q", n, label)
+			}
+			if _, dup := exprs[label]; dup {
+				return nil, fmt.Errorf("line %d: duplicate label %q", n, label)
+			}
+			e, perr := Parse(strings.NewReader(src), opts...)
+			if perr != nil {
+				return nil, fmt.Errorf("line %d: %s: %s", n, label, perr)
+			}
+			exprs[label] = e
+		}
+		if err != nil {
+			return exprs, nil
+		}
+	}
+}
+
+// isIdentifier reports whether s is a non-empty sequence of letters, digits and
+// underscores that does not start with a digit.
+func isIdentifier(s string) bool {
+	for i, c := range s {
+		if !(unicode.IsLetter(c) || c == '_' || i > 0 && unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,45 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMany(t *testing.T) {
+	input := "total: 2 + 3\n\n  net_2 : 10\t/ 2\nrate:1.5*-2"
+	exprs, err := ParseMany(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseMany failed: %v", err)
+	}
+	want := map[string]float64{"total": 5, "net_2": 5, "rate": -3}
+	if len(exprs) != len(want) {
+		t.Errorf("ParseMany returned %d expressions, want %d", len(exprs), len(want))
+	}
+	for label, w := range want {
+		e, ok := exprs[label]
+		if !ok {
+			t.Errorf("label %q missing", label)
+			continue
+		}
+		if got, err := e.Eval(); err != nil || got != w {
+			t.Errorf("%s = %v, %v, want %v", label, got, err, w)
+		}
+	}
+}
+
+func TestParseManyErrors(t *testing.T) {
+	tests := []string{
+		"1 + 2",            // no label
+		"2x: 1",            // not an identifier
+		"a b: 1",           // not an identifier
+		": 1",              // empty label
+		"a: 1\nb: 2\na: 3", // duplicate
+		"a: 1\nb: 2 +",     // invalid expression
+		"a: 1\nb: 2 : 3\n", // a second colon is part of the expression
+	}
+	for _, input := range tests {
+		if _, err := ParseMany(strings.NewReader(input)); err == nil {
+			t.Errorf("ParseMany(%q) succeeded, want error", input)
+		}
+	}
+}
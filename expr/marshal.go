@@ -0,0 +1,240 @@
+package expr
+
+import (
+	bin "encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode"
+)
+
+// The binary format starts with the header "CA" and a version byte, followed by the
+// nodes of the tree in prefix order. Every node starts with a tag byte:
+//
+//	tagInt      zigzag varint of an integral number
+//	tagFloat    8 bytes of a float64, little endian
+//	tagLiteral  uvarint length and text of a number parsed with WithSourceLiterals
+//	tagUnary    uvarint operator, then the operand
+//	tagBinary   uvarint operator, then both operands
+//	tagCall     uvarint length and name of the function, uvarint number of arguments, then the arguments
+//	tagVariable uvarint length and name of the variable
+//	tagConstant uvarint length and name of the constant
+//
+// A new version is needed for any change of the format that old readers cannot handle.
+const binaryVersion = 1
+
+const (
+	tagInt byte = iota + 1
+	tagFloat
+	tagLiteral
+	tagUnary
+	tagBinary
+	tagCall
+	tagVariable
+	tagConstant
+)
+
+// MarshalBinary encodes the expression in a compact binary format, much smaller and
+// faster to read back than the canonical text. Nodes other than those built by Parse
+// cannot be encoded.
+func MarshalBinary(e Expr) ([]byte, error) {
+	return appendBinary([]byte{'C', 'A', binaryVersion}, e)
+}
+
+func appendBinary(b []byte, e Expr) ([]byte, error) {
+	var err error
+	switch e := e.(type) {
+	case num:
+		f := float64(e)
+		if math.Abs(f) <= 1<<53 && f == math.Trunc(f) && (f != 0 || !math.Signbit(f)) {
+			return bin.AppendVarint(append(b, tagInt), int64(f)), nil
+		}
+		return bin.LittleEndian.AppendUint64(append(b, tagFloat), math.Float64bits(f)), nil
+	case literal:
+		b = bin.AppendUvarint(append(b, tagLiteral), uint64(len(e.text)))
+		return append(b, e.text...), nil
+	case unary:
+		b = bin.AppendUvarint(append(b, tagUnary), uint64(e.op))
+		return appendBinary(b, e.x)
+	case binary:
+		b = bin.AppendUvarint(append(b, tagBinary), uint64(e.op))
+		if b, err = appendBinary(b, e.x); err != nil {
+			return nil, err
+		}
+		return appendBinary(b, e.y)
+	case call:
+		b = bin.AppendUvarint(append(b, tagCall), uint64(len(e.name)))
+		b = bin.AppendUvarint(append(b, e.name...), uint64(len(e.args)))
+		for _, a := range e.args {
+			if b, err = appendBinary(b, a); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case variable:
+		b = bin.AppendUvarint(append(b, tagVariable), uint64(len(e)))
+		return append(b, e...), nil
+	case constant:
+		b = bin.AppendUvarint(append(b, tagConstant), uint64(len(e)))
+		return append(b, e...), nil
+	}
+	return nil, fmt.Errorf("cannot marshal %v", e)
+}
+
+// UnmarshalBinary decodes an expression encoded by MarshalBinary.
+func UnmarshalBinary(data []byte) (Expr, error) {
+	if len(data) < 3 || data[0] != 'C' || data[1] != 'A' {
+		return nil, fmt.Errorf("not a binary expression")
+	}
+	if data[2] != binaryVersion {
+		return nil, fmt.Errorf("unsupported binary expression version %d", data[2])
+	}
+	d := decoder{data: data, pos: 3}
+	e, err := d.expr()
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("%d bytes of trailing data", len(d.data)-d.pos)
+	}
+	return e, nil
+}
+
+// decoder reads the nodes of a binary expression one after the other.
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) expr() (Expr, error) {
+	if d.pos >= len(d.data) {
+		return nil, fmt.Errorf("unexpected end of data")
+	}
+	tag := d.data[d.pos]
+	d.pos++
+	switch tag {
+	case tagInt:
+		i, n := bin.Varint(d.data[d.pos:])
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid integer at byte %d", d.pos)
+		}
+		d.pos += n
+		return num(i), nil
+	case tagFloat:
+		if len(d.data)-d.pos < 8 {
+			return nil, fmt.Errorf("unexpected end of data")
+		}
+		f := math.Float64frombits(bin.LittleEndian.Uint64(d.data[d.pos:]))
+		d.pos += 8
+		return num(f), nil
+	case tagLiteral:
+		text, err := d.text()
+		if err != nil {
+			return nil, err
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q: %s", text, err)
+		}
+		return literal{num(f), text}, nil
+	case tagUnary:
+		op, err := d.op()
+		if err != nil {
+			return nil, err
+		}
+		x, err := d.expr()
+		if err != nil {
+			return nil, err
+		}
+		return unary{op, x}, nil
+	case tagBinary:
+		op, err := d.op()
+		if err != nil {
+			return nil, err
+		}
+		x, err := d.expr()
+		if err != nil {
+			return nil, err
+		}
+		y, err := d.expr()
+		if err != nil {
+			return nil, err
+		}
+		return binary{op, x, y}, nil
+	case tagCall:
+		name, err := d.text()
+		if err != nil {
+			return nil, err
+		}
+		n, err := d.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(d.data)-d.pos) { // every argument takes a byte at least
+			return nil, fmt.Errorf("unexpected end of data")
+		}
+		if err := checkArity(name, int(n)); err != nil {
+			return nil, err
+		}
+		c := call{name, make([]Expr, n)}
+		for i := range c.args {
+			if c.args[i], err = d.expr(); err != nil {
+				return nil, err
+			}
+		}
+		return c, nil
+	case tagVariable:
+		name, err := d.text()
+		if err != nil {
+			return nil, err
+		}
+		if !isIdentifier(name) {
+			return nil, fmt.Errorf("invalid variable name %q", name)
+		}
+		return variable(name), nil
+	case tagConstant:
+		name, err := d.text()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := constants[name]; !ok {
+			return nil, fmt.Errorf("unknown constant %q", name)
+		}
+		return constant(name), nil
+	}
+	return nil, fmt.Errorf("invalid tag %d at byte %d", tag, d.pos-1)
+}
+
+// text reads a string prefixed with its length.
+func (d *decoder) text() (string, error) {
+	l, err := d.uvarint()
+	if err != nil {
+		return "", err
+	}
+	if l > uint64(len(d.data)-d.pos) {
+		return "", fmt.Errorf("unexpected end of data")
+	}
+	s := string(d.data[d.pos : d.pos+int(l)])
+	d.pos += int(l)
+	return s, nil
+}
+
+func (d *decoder) uvarint() (uint64, error) {
+	u, n := bin.Uvarint(d.data[d.pos:])
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid varint at byte %d", d.pos)
+	}
+	d.pos += n
+	return u, nil
+}
+
+func (d *decoder) op() (rune, error) {
+	u, err := d.uvarint()
+	if err != nil {
+		return 0, err
+	}
+	if u > unicode.MaxRune {
+		return 0, fmt.Errorf("invalid operator %d", u)
+	}
+	return rune(u), nil
+}
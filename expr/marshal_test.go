@@ -0,0 +1,79 @@
+package expr
+
+import (
+	"math"
+	"os"
+	"testing"
+)
+
+func TestMarshalBinaryRoundTrip(t *testing.T) {
+	exprs := []Expr{
+		mustParse(t, "1 + -2 * (3.25 - 1e300) / 0.1"),
+		binary{'-', num(math.Inf(1)), unary{'-', num(math.Copysign(0, -1))}},
+		literal{num(0.1), "0.10"},
+		num(-9007199254740992),
+		mustParse(t, "max(1, sqrt(x + 2), y_2) * pi"),
+	}
+	for _, e := range exprs {
+		data, err := MarshalBinary(e)
+		if err != nil {
+			t.Fatalf("MarshalBinary(%v) failed: %v", e, err)
+		}
+		got, err := UnmarshalBinary(data)
+		if err != nil {
+			t.Fatalf("UnmarshalBinary of %v failed: %v", e, err)
+		}
+		if !equalExpr(got, e) {
+			t.Errorf("UnmarshalBinary(MarshalBinary(%v)) = %v", e, got)
+		}
+	}
+}
+
+func TestMarshalBinarySize(t *testing.T) {
+	f, err := os.Open("../testdata/1k.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	e, err := Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := MarshalBinary(e)
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	got, err := UnmarshalBinary(data)
+	if err != nil {
+		t.Fatalf("UnmarshalBinary failed: %v", err)
+	}
+	if Canonical(got) != Canonical(e) {
+		t.Error("the 1k expression does not survive the round trip")
+	}
+	if text := len(Canonical(e)); len(data) >= text {
+		t.Errorf("binary form has %d bytes, want less than the %d of the text", len(data), text)
+	}
+}
+
+func TestUnmarshalBinaryInvalid(t *testing.T) {
+	valid, _ := MarshalBinary(mustParse(t, "1 + 2"))
+	for _, data := range [][]byte{
+		nil,
+		[]byte("1 + 2"),
+		{'C', 'A', 99},                           // unknown version
+		{'C', 'A', 2, tagInt, 2},                 // unknown version
+		valid[:len(valid)-1],                     // truncated
+		append(valid[:len(valid):len(valid)], 0), // trailing data
+		{'C', 'A', binaryVersion, tagCall, 3, 'f', 'o', 'o', 1, tagInt, 2},                 // unknown function
+		{'C', 'A', binaryVersion, tagCall, 4, 's', 'q', 'r', 't', 2, tagInt, 2, tagInt, 2}, // wrong number of arguments
+		{'C', 'A', binaryVersion, tagVariable, 2, '1', 'x'},                                // invalid name
+	} {
+		if e, err := UnmarshalBinary(data); err == nil {
+			t.Errorf("UnmarshalBinary(%q) = %v, want error", data, e)
+		}
+	}
+	if _, err := MarshalBinary(panicky{}); err == nil {
+		t.Error("MarshalBinary of a foreign node succeeded, want error")
+	}
+}
@@ -0,0 +1,103 @@
+package expr
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// EvalMod evaluates the expression in the ring of integers modulo m and returns
+// a result between 0 and m-1. All numbers must be integers. A division multiplies
+// by the modular inverse of the divisor, which only exists if the divisor and m are
+// coprime; otherwise it is an error. With a prime m, any nonzero divisor works.
+// The exponent of a power is evaluated as a plain integer, not modulo m, and a negative
+// exponent raises the inverse of the base, so 2 ^ -1 is 4 modulo 7. A remainder a % b
+// does not carry over to residues either; it is taken of the integers a and b, which
+// are evaluated like in EvalBigInt, and then reduced: 10 % 4 is 2 modulo 7, though
+// 10 is 3 modulo 7.
+func EvalMod(e Expr, m int64) (int64, error) {
+	if m <= 0 {
+		return 0, fmt.Errorf("modulus %d is not positive", m)
+	}
+	r, err := evalMod(e, big.NewInt(m))
+	if err != nil {
+		return 0, err
+	}
+	return r.Int64(), nil
+}
+
+// evalMod returns the value of e reduced to the range [0, m).
+func evalMod(e Expr, m *big.Int) (*big.Int, error) {
+	switch e := e.(type) {
+	case num, literal:
+		i, err := evalBigInt(e, false)
+		if err != nil {
+			return nil, err
+		}
+		return i.Mod(i, m), nil
+
+	case unary:
+		x, err := evalMod(e.x, m)
+		if err != nil {
+			return nil, err
+		}
+		switch e.op {
+		case '+':
+			return x, nil
+		case '-':
+			return x.Mod(x.Neg(x), m), nil
+		}
+		return nil, fmt.Errorf("unsupported unary operator: %q", e.op)
+
+	case binary:
+		if e.op == '%' {
+			r, err := evalBigInt(e, false)
+			if err != nil {
+				return nil, err
+			}
+			return r.Mod(r, m), nil
+		}
+		x, err := evalMod(e.x, m)
+		if err != nil {
+			return nil, err
+		}
+		if e.op == '^' {
+			return modPow(x, e.y, m)
+		}
+		y, err := evalMod(e.y, m)
+		if err != nil {
+			return nil, err
+		}
+		switch e.op {
+		case '+':
+			x.Add(x, y)
+		case '-':
+			x.Sub(x, y)
+		case '*':
+			x.Mul(x, y)
+		case '/':
+			inv := new(big.Int).ModInverse(y, m)
+			if inv == nil {
+				return nil, fmt.Errorf("%v has no inverse modulo %v", y, m)
+			}
+			x.Mul(x, inv)
+		default:
+			return nil, fmt.Errorf("unsupported binary operator: %q", e.op)
+		}
+		return x.Mod(x, m), nil
+	}
+	return nil, fmt.Errorf("cannot evaluate %v modulo %v", e, m)
+}
+
+// modPow returns x ^ exp modulo m. Reducing the exponent modulo m would change the power,
+// 2 ^ 5 is not 2 ^ 2 modulo 3, so it is evaluated in unbounded integer arithmetic.
+func modPow(x *big.Int, exp Expr, m *big.Int) (*big.Int, error) {
+	n, err := evalBigInt(exp, false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent %v: %s", exp, err)
+	}
+	z := new(big.Int).Exp(x, n, m)
+	if z == nil {
+		return nil, fmt.Errorf("%v has no inverse modulo %v", x, m)
+	}
+	return z.Mod(z, m), nil
+}
@@ -0,0 +1,57 @@
+package expr
+
+import "testing"
+
+func TestEvalMod(t *testing.T) {
+	tests := []struct {
+		input string
+		m     int64
+		want  int64
+	}{
+		{"5 + 4", 7, 2},
+		{"3 * 5", 7, 1},
+		{"3 / 2", 7, 5}, // 2 * 4 = 1 (mod 7), so 3/2 = 3*4 = 12 = 5
+		{"2 - 5", 7, 4},
+		{"-1", 7, 6},
+		{"1 / 3 + 2 / 3", 11, 1},
+		{"123456789 * 987654321", 1000000007, 259106859},
+		{"4 + 4", 1, 0},
+		{"2 ^ 10", 1000, 24},
+		{"2 ^ 5", 3, 2}, // the exponent is not reduced modulo 3
+		{"2 ^ -1", 7, 4},
+		{"3 ^ 2 ^ 100", 1000000007, 870513414},
+		{"7 ^ 0", 5, 1},
+		{"10 % 4", 7, 2}, // of 10 and 4, not of their residues 3 and 4
+		{"-7 % 3 + 1", 5, 0},
+		{"2 ^ (10 % 4)", 100, 4},
+	}
+	for _, test := range tests {
+		got, err := EvalMod(mustParse(t, test.input), test.m)
+		if err != nil {
+			t.Fatalf("EvalMod(%q, %d) failed: %v", test.input, test.m, err)
+		}
+		if got != test.want {
+			t.Errorf("EvalMod(%q, %d) = %d, want %d", test.input, test.m, got, test.want)
+		}
+	}
+}
+
+func TestEvalModErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		m     int64
+	}{
+		{"1 / 2", 8},   // 2 has no inverse modulo 8
+		{"1 / 7", 7},   // neither has 0
+		{"1.5 + 1", 7}, // not an integer
+		{"1 + 1", 0},
+		{"2 ^ -1", 8},  // no inverse
+		{"2 ^ 0.5", 7}, // not an integer exponent
+		{"1 % 0", 7},
+	}
+	for _, test := range tests {
+		if _, err := EvalMod(mustParse(t, test.input), test.m); err == nil {
+			t.Errorf("EvalMod(%q, %d) succeeded, want error", test.input, test.m)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package expr
+
+import (
+	"math"
+	"sort"
+)
+
+// Optimize returns an equivalent expression that evaluates more accurately in floating
+// point. The terms of every chain of additions are reordered to add up the terms of
+// smaller magnitude first, so that they are not absorbed one by one by a large term.
+// The arguments of function calls are optimized on their own.
+// Other operators are left as they are, since only '+' can be freely reordered in a
+// sum and products do not gain accuracy from reordering. Unlike the original
+// association, the result may differ in the last digits; in exact arithmetic it is the same.
+func Optimize(e Expr) Expr {
+	switch e := e.(type) {
+	case unary:
+		return unary{e.op, Optimize(e.x)}
+	case binary:
+		if e.op != '+' {
+			return binary{e.op, Optimize(e.x), Optimize(e.y)}
+		}
+		type term struct {
+			e   Expr
+			mag float64
+		}
+		var terms []term
+		for _, t := range sumTerms(e, nil) {
+			t = Optimize(t)
+			v, err := t.Eval()
+			if err != nil {
+				v = math.Inf(1) // keep failing terms to the end, the sum fails anyway
+			}
+			terms = append(terms, term{t, math.Abs(v)})
+		}
+		sort.SliceStable(terms, func(i, j int) bool { return terms[i].mag < terms[j].mag })
+
+		sum := terms[0].e
+		for _, t := range terms[1:] {
+			sum = binary{'+', sum, t.e}
+		}
+		return sum
+	case call:
+		args := make([]Expr, len(e.args))
+		for i, a := range e.args {
+			args[i] = Optimize(a)
+		}
+		return call{e.name, args}
+	}
+	return e
+}
+
+// sumTerms appends the operands of the chain of additions e to terms.
+func sumTerms(e Expr, terms []Expr) []Expr {
+	if b, ok := e.(binary); ok && b.op == '+' {
+		return sumTerms(b.y, sumTerms(b.x, terms))
+	}
+	return append(terms, e)
+}
+
+// CompensatedSum evaluates the expression like Eval, but adds up the terms of its top-level
+// chain of additions and subtractions with Neumaier's compensated summation, so that
+// small terms are not lost next to large ones. The terms themselves are evaluated by Eval.
+func CompensatedSum(e Expr) (float64, error) {
+	var sum, c float64 // c collects the low-order bits lost by sum
+	for _, t := range signedTerms(e, false, nil) {
+		v, err := t.e.Eval()
+		if err != nil {
+			return 0, err
+		}
+		if t.neg {
+			v = -v
+		}
+		s := sum + v
+		if math.Abs(sum) >= math.Abs(v) {
+			c += (sum - s) + v
+		} else {
+			c += (v - s) + sum
+		}
+		sum = s
+	}
+	return sum + c, nil
+}
+
+// signedTerm is a term of a sum, which is subtracted if neg is set.
+type signedTerm struct {
+	e   Expr
+	neg bool
+}
+
+// signedTerms appends the terms of the chain of additions and subtractions e to terms.
+func signedTerms(e Expr, neg bool, terms []signedTerm) []signedTerm {
+	if b, ok := e.(binary); ok && (b.op == '+' || b.op == '-') {
+		terms = signedTerms(b.x, neg, terms)
+		return signedTerms(b.y, neg != (b.op == '-'), terms)
+	}
+	return append(terms, signedTerm{e, neg})
+}
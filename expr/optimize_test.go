@@ -0,0 +1,92 @@
+package expr
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestOptimizeImprovesAccuracy(t *testing.T) {
+	// every single 1 is absorbed by the large term when added to it
+	input := "1e16" + strings.Repeat(" + 1", 1000) + " - 1e16 * (0.5 + 0.5)"
+	e := mustParse(t, input)
+
+	exact, err := EvalRat(e)
+	if err != nil {
+		t.Fatalf("EvalRat failed: %v", err)
+	}
+	want, _ := exact.Float64()
+
+	naive, _ := e.Eval()
+	optimized, err := Optimize(e).Eval()
+	if err != nil {
+		t.Fatalf("evaluation of the optimized expression failed: %v", err)
+	}
+	if errorOf(optimized, exact) >= errorOf(naive, exact) {
+		t.Errorf("optimized result %g is not closer to %g than %g", optimized, want, naive)
+	}
+	if optimized != want {
+		t.Errorf("optimized result = %g, want %g", optimized, want)
+	}
+
+	// sums inside the arguments of calls are reordered too
+	e = mustParse(t, "sqrt(1e16 + 1 + -1e16 + 1)")
+	if got, err := Optimize(e).Eval(); err != nil || got != math.Sqrt2 {
+		t.Errorf("optimized %v = %v, %v, want %v", e, got, err, math.Sqrt2)
+	}
+}
+
+func errorOf(x float64, exact *big.Rat) float64 {
+	d := new(big.Rat).SetFloat64(x)
+	f, _ := d.Sub(d, exact).Float64()
+	return math.Abs(f)
+}
+
+func TestOptimizeKeepsValue(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"100 + 1 + 10", "1 + 10 + 100"},
+		{"-100 + 1 - 10", "1 + -100 - 10"}, // only the chain of additions is reordered
+		{"(30 + 2) * (1000 + 1)", "(2 + 30) * (1 + 1000)"},
+		{"10 + (1 / 0) + 1", "1 + 10 + 1 / 0"}, // failing terms go last
+		{"max(100 + 1, 2) + 1000", "max(1 + 100, 2) + 1000"},
+	}
+	for _, test := range tests {
+		if got := Canonical(Optimize(mustParse(t, test.input))); got != test.want {
+			t.Errorf("Optimize(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+
+	content := mustParse(t, strings.Repeat("1.1 * 3 + 2 - 1e5 + ", 200)+"7")
+	want, _ := EvalRat(content)
+	got, _ := Optimize(content).Eval()
+	if errorOf(got, want) > 1e-6 {
+		t.Errorf("optimized result %g differs from %s", got, want.FloatString(10))
+	}
+}
+
+func TestCompensatedSum(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"1e16" + strings.Repeat(" + 1", 10) + " - 1e16", 10},
+		{"1e16 - (1e16 - 3 + 1) + 2 * 3", 8},
+		{"1 + 2 * 3 - 4 / 2", 5},
+	}
+	for _, test := range tests {
+		got, err := CompensatedSum(mustParse(t, test.input))
+		if err != nil {
+			t.Fatalf("CompensatedSum(%q) failed: %v", test.input, err)
+		}
+		if got != test.want {
+			t.Errorf("CompensatedSum(%q) = %v, want %v", test.input, got, test.want)
+		}
+	}
+	if _, err := CompensatedSum(mustParse(t, "1 + 1 / 0")); err == nil {
+		t.Error("CompensatedSum of a division by zero succeeded, want error")
+	}
+}
@@ -0,0 +1,88 @@
+package expr
+
+import "context"
+
+// An Option configures the behaviour of Parse and EvalParse.
+type Option func(*config)
+
+// config holds the settings of the parser, as set by the options.
+type config struct {
+	maxParenDepth  int  // 0 means no limit
+	sourceLiterals bool // keep the source text of numbers
+	emptyAsZero    bool // empty input is the number 0
+	decimalComma   bool // ',' is the decimal point of numbers
+	strictEval     bool // EvalParse fails on evaluation errors
+	finiteEval     bool // EvalParse fails on infinite or NaN partial results
+	foldSigns      bool // a sign right before a number is part of it
+
+	allowedFuncs map[string]bool // nil means all functions can be called
+
+	ctx context.Context // set by ParseContext only
+}
+
+// WithMaxParenDepth makes parsing fail when parentheses are nested more than n levels deep.
+// This is a guard against adversarial input like "((((...))))", which would otherwise
+// drive the recursive parser arbitrarily deep. Note that the limit only counts
+// parentheses; the nesting of operators without parentheses is not limited by it.
+func WithMaxParenDepth(n int) Option {
+	return func(c *config) { c.maxParenDepth = n }
+}
+
+// WithSourceLiterals makes Parse keep the text of every number as written in the source.
+// The numbers then print exactly as written (0.1 rather than 0.10), and EvalRat and the
+// integer evaluators work on the exact decimal text instead of the float, so that
+// integers beyond 2^53 keep all their digits. It costs one string per number.
+func WithSourceLiterals() Option {
+	return func(c *config) { c.sourceLiterals = true }
+}
+
+// WithEmptyAsZero makes Parse and EvalParse accept empty or whitespace-only input as
+// the number 0, for lenient contexts like summing optional fields. Without it, empty
+// input is an error.
+func WithEmptyAsZero() Option {
+	return func(c *config) { c.emptyAsZero = true }
+}
+
+// WithDecimalComma makes Parse, EvalParse and ParseStream read a ',' directly between
+// digits as the decimal point, so that "3,14 + 1" is 4.14. There must be no space around
+// the comma, and the fraction cannot have a decimal point of its own. Since the comma
+// then belongs to the numbers, the arguments of function calls are separated by ';'
+// instead, as in max(1,5; 2).
+func WithDecimalComma() Option {
+	return func(c *config) { c.decimalComma = true }
+}
+
+// WithStrictEval makes EvalParse fail when evaluating a part of the expression fails,
+// e.g. on a division by zero. Without it, EvalParse carries on as if the failed part
+// were 0. The option has no effect on Parse, which does not evaluate.
+func WithStrictEval() Option {
+	return func(c *config) { c.strictEval = true }
+}
+
+// WithFiniteEval makes EvalParse fail when a part of the expression evaluates to an
+// infinity or NaN, even if a later operation brings the result back into range, like
+// 1 / (1e308 * 10). It is the check of EvalFinite for the in-place evaluation.
+func WithFiniteEval() Option {
+	return func(c *config) { c.finiteEval = true }
+}
+
+// WithFoldUnaryLiterals makes Parse read a sign directly in front of a number as part of
+// the number, so that -5 is the single number -5 instead of a sign applied to 5. This
+// saves a node for every signed number. A sign in front of parentheses, like -(5), or in
+// front of another sign stays a separate node. The value of the expression is the same.
+func WithFoldUnaryLiterals() Option {
+	return func(c *config) { c.foldSigns = true }
+}
+
+// WithAllowedFunctions makes Parse and EvalParse reject calls of any function not named
+// here, built in or registered, e.g. to let the users of a server call sqrt and abs but
+// nothing else. Without it, all functions can be called. Naming no function at all
+// forbids every call.
+func WithAllowedFunctions(names ...string) Option {
+	return func(c *config) {
+		c.allowedFuncs = make(map[string]bool, len(names))
+		for _, name := range names {
+			c.allowedFuncs[name] = true
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package expr
+
+// The functions in this package can be used to parse an arthimetic expression in text form into an ast-like data structure.
+// We use a lexer and a parse algorithm for symbolic expressions; both ideas come from Donovan & Kernighan (2016)
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/scanner"
+)
+
+// lexer
+type lexer struct {
+	scan  scanner.Scanner
+	token rune // current token, used as lookahead
+	cfg   config
+
+	symbols   int  // numbers and operators scanned so far, as counted by Len
+	parens    int  // current nesting depth of parentheses
+	maxParens int  // deepest nesting of parentheses seen so far
+	opened    bool // the current token directly follows a '('
+	patterns  bool // accept ?name wildcards, only used to parse rewrite rules
+
+	ctxErr     error // set when the context of ParseContext is done
+	sinceCheck int   // tokens scanned since the context was last checked
+}
+
+// newLexer returns a lexer reading from r, configured with the given options.
+func newLexer(r io.Reader, opts []Option) *lexer {
+	lex := new(lexer)
+	lex.scan.Init(r)
+
+	// configure the lexer
+	// recognise as tokens: symbols, integers and floats
+	lex.scan.Mode = scanner.ScanIdents | scanner.ScanInts | scanner.ScanFloats
+
+	for _, opt := range opts {
+		opt(&lex.cfg)
+	}
+	return lex
+}
+
+func (lex *lexer) text() string { return lex.scan.TokenText() } // return last scanned token as text
+
+// next consumes and stores the next token. Once the context of ParseContext is done,
+// the input seems to end there.
+func (lex *lexer) next() {
+	if lex.cfg.ctx != nil && lex.cancelled() {
+		lex.token = scanner.EOF
+		return
+	}
+	lex.token = lex.scan.Scan()
+	if lex.token != '(' && lex.token != ')' && lex.token != scanner.EOF {
+		lex.symbols++
+	}
+}
+
+// ctxCheckInterval is the number of tokens after which ParseContext looks at its context.
+const ctxCheckInterval = 1024
+
+// cancelled reports whether the context of ParseContext is done. The context is
+// only looked at every ctxCheckInterval tokens, to keep the check cheap.
+func (lex *lexer) cancelled() bool {
+	if lex.ctxErr != nil {
+		return true
+	}
+	if lex.sinceCheck++; lex.sinceCheck < ctxCheckInterval {
+		return false
+	}
+	lex.sinceCheck = 0
+	lex.ctxErr = lex.cfg.ctx.Err()
+	return lex.ctxErr != nil
+}
+
+// numberText returns the text of the current number token. With WithDecimalComma, a ','
+// right after an integer is its decimal point, and the digits after it are scanned as part
+// of the number, which then ends on their token. The text always uses '.' as the decimal point.
+func (lex *lexer) numberText() (string, error) {
+	text := lex.text()
+	if !lex.cfg.decimalComma || lex.token != scanner.Int || lex.scan.Peek() != ',' {
+		return text, nil
+	}
+	lex.scan.Next() // consume ','
+	if r := lex.scan.Peek(); r < '0' || r > '9' {
+		return "", fmt.Errorf("missing digits after the decimal comma of %s", text)
+	}
+	lex.next() // scan the fraction
+	return text + "." + lex.text(), nil
+}
+
+// openParen keeps track of the nesting of parentheses when a '(' is consumed.
+func (lex *lexer) openParen() error {
+	lex.parens++
+	lex.maxParens = max(lex.maxParens, lex.parens)
+	if lex.cfg.maxParenDepth > 0 && lex.parens > lex.cfg.maxParenDepth {
+		return fmt.Errorf("parentheses nested deeper than %d levels", lex.cfg.maxParenDepth)
+	}
+	return nil
+}
+
+// closeParen keeps track of the nesting of parentheses when a ')' is consumed.
+func (lex *lexer) closeParen() { lex.parens-- }
+
+// String returns a string describing the current state of the lexer (the current token)
+// for use in errors.
+func (lex *lexer) String() string {
+	switch lex.token {
+	case scanner.EOF:
+		return "end of file"
+	case scanner.Ident:
+		return fmt.Sprintf("identifier %s", lex.text())
+	case scanner.Int, scanner.Float:
+		return fmt.Sprintf("number %s", lex.text())
+	}
+	return fmt.Sprintf("%q", rune(lex.token)) // any other rune
+}
+
+func priority(op rune) int {
+	switch op {
+	case '^':
+		return 3 // parsed by parsePower, right associative
+	case '*', '/', '%':
+		return 2
+	case '+', '-':
+		return 1
+	}
+	return 0
+}
+
+// Parse parses the content from the input reader as an arithmetic expression.
+// It uses lazy loading. The buffering management is done by the scanner in the lexer.
+func Parse(r io.Reader, opts ...Option) (Expr, error) {
+	e, _, err := parse(r, opts)
+	return e, err
+}
+
+// ParseContext parses the input like Parse, but stops with ctx.Err() when ctx is done,
+// so that a server can abort parsing a huge input. The context is checked before
+// reading anything and then every ctxCheckInterval tokens while scanning.
+func ParseContext(ctx context.Context, r io.Reader, opts ...Option) (Expr, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	opts = append(opts[:len(opts):len(opts)], func(c *config) { c.ctx = ctx })
+	e, _, err := parse(r, opts)
+	return e, err
+}
+
+// ParseTee parses the input like Parse and also returns the source text it consumed,
+// e.g. for logging exactly what was parsed when the reader cannot be rewound.
+// After an error, the text ends right after the token at which parsing failed.
+func ParseTee(r io.Reader, opts ...Option) (Expr, string, error) {
+	var src strings.Builder
+	e, lex, err := parse(io.TeeReader(r, &src), opts)
+	text := src.String()
+	if end := lex.scan.Pos().Offset; end < len(text) {
+		text = text[:end]
+	}
+	return e, text, err
+}
+
+// parse parses the whole input and also returns the lexer, for the statistics it collected
+// and the position where parsing stopped. The lexer is returned even on errors.
+func parse(r io.Reader, opts []Option) (Expr, *lexer, error) {
+	lex := newLexer(r, opts)
+
+	lex.next() // initial lookahead
+	if lex.token == scanner.EOF && lex.cfg.emptyAsZero && lex.ctxErr == nil {
+		return num(0), lex, nil
+	}
+	e, err := parseExpr(lex)
+	if lex.ctxErr != nil {
+		return nil, lex, lex.ctxErr // the input was cut short, whatever was parsed is incomplete
+	}
+	if err != nil {
+		return nil, lex, fmt.Errorf("could not parse %s: %s", lex, err)
+	}
+	if lex.token != scanner.EOF {
+		return nil, lex, fmt.Errorf("unexpected %s", lex)
+	}
+
+	return e, lex, nil
+}
+
+// parseExpr is just an entry point to parseBinary with a low operator priority of 1
+// this represents a sum A + B, or a rest A - B
+func parseExpr(lex *lexer) (Expr, error) { return parseBinary(lex, 1) }
+
+// parseBinary parses a binary operation with its operands: -A + (B) or -A * (B)
+// it stops when it encounters an operator of lower prio than prio0
+func parseBinary(lex *lexer, prio0 int) (Expr, error) {
+	left, err := parseUnary(lex)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse expression in unary %s: %s", lex, err)
+	}
+
+	for prio := priority(lex.token); prio >= prio0; prio-- {
+		for priority(lex.token) == prio {
+			op := lex.token
+			lex.next() // consume operator and look ahead
+			right, err := parseBinary(lex, prio+1)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse expression in unary %s: %s", lex, err)
+			}
+			left = binary{op, left, right}
+		}
+	}
+	return left, nil
+}
+
+// parses a signed number or a signed parenthesis: -A or -(...)
+// A signed number alone in parentheses, like (-5), is a single number, as Canonical writes it.
+func parseUnary(lex *lexer) (Expr, error) {
+	opened := lex.opened
+	lex.opened = false
+	if isPrefix(lex.token) {
+		op := lex.token
+		lex.next() // consume '+', '-' or prefix operator
+		literalNext := lex.token == scanner.Int || lex.token == scanner.Float
+		e, err := parseUnary(lex)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse expression in unary %s: %s", lex, err)
+		}
+		if (lex.cfg.foldSigns || opened && lex.token == ')') && literalNext && (op == '-' || op == '+') {
+			if folded, ok := foldSign(op, e); ok {
+				return folded, nil
+			}
+		}
+		return unary{op, e}, nil
+	}
+	// parse number or parenthesis group after the sign
+	return parsePower(lex)
+}
+
+// parsePower parses a number or a parenthesis group, raised to a power if '^' follows: A ^ -B.
+// The exponent is parsed by parseUnary, so it may have a sign and '^' is right associative,
+// 2 ^ 3 ^ 2 = 2 ^ (3 ^ 2), while a sign in front of the base applies to the power, -2 ^ 2 = -(2 ^ 2).
+func parsePower(lex *lexer) (Expr, error) {
+	base, err := parsePrimary(lex)
+	if err != nil || lex.token != '^' {
+		return base, err
+	}
+	lex.next() // consume '^'
+	exp, err := parseUnary(lex)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse exponent %s: %s", lex, err)
+	}
+	return binary{'^', base, exp}, nil
+}
+
+// parseCall parses a function call name(A, B, ...), with the arguments parsed by parseArg.
+// With WithDecimalComma, the arguments are separated by ';' instead of ','.
+// The application name @ A is the call name(A), with A parsed by parseApplied, which
+// parses a signed operand: abs @ -5 * 2 is abs(-5) * 2, and sqrt @ abs @ -16 is
+// sqrt(abs(-16)).
+func parseCall(lex *lexer, parseArg, parseApplied func(*lexer) (Expr, error)) (Expr, error) {
+	c := call{name: lex.text()}
+	if _, ok := functions[c.name]; !ok {
+		return nil, fmt.Errorf("unknown function %s", c.name)
+	}
+	if lex.cfg.allowedFuncs != nil && !lex.cfg.allowedFuncs[c.name] {
+		return nil, fmt.Errorf("function %s is not allowed", c.name)
+	}
+	lex.next() // consume name
+	if lex.token == '@' {
+		lex.next() // consume '@'
+		arg, err := parseApplied(lex)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the operand of %s @: %s", c.name, err)
+		}
+		if err := checkArity(c.name, 1); err != nil {
+			return nil, err
+		}
+		c.args = []Expr{arg}
+		return c, nil
+	}
+	if lex.token != '(' {
+		return nil, fmt.Errorf("got %s, want '(' or '@' after %s", lex, c.name)
+	}
+	if err := lex.openParen(); err != nil {
+		return nil, err
+	}
+	lex.next() // consume '('
+
+	sep := ','
+	if lex.cfg.decimalComma {
+		sep = ';'
+	}
+	for lex.token != ')' {
+		if len(c.args) > 0 {
+			if lex.token != sep {
+				return nil, fmt.Errorf("got %s, want %q or ')'", lex, sep)
+			}
+			lex.next() // consume separator
+		}
+		arg, err := parseArg(lex)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse argument %d of %s: %s", len(c.args)+1, c.name, err)
+		}
+		c.args = append(c.args, arg)
+	}
+	lex.closeParen()
+	lex.next() // consume ')'
+
+	if err := checkArity(c.name, len(c.args)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// foldSign returns the number e with the sign op applied, if e is a number.
+func foldSign(op rune, e Expr) (Expr, bool) {
+	n, ok := numValue(e)
+	if !ok {
+		return nil, false
+	}
+	if op == '-' {
+		n = -n
+	}
+	if l, ok := e.(literal); ok {
+		return literal{n, string(op) + l.text}, true
+	}
+	return n, true
+}
+
+// parsePrimary parses a number or a parenthesis group: N or (...)
+func parsePrimary(lex *lexer) (Expr, error) {
+	switch lex.token {
+
+	// parse an integer or a float number
+	case scanner.Int, scanner.Float:
+		text, err := lex.numberText()
+		if err != nil {
+			return nil, err
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the float number %s: %s", lex, err)
+		}
+		lex.next() // consume number
+		if lex.cfg.sourceLiterals {
+			return literal{num(f), text}, nil
+		}
+		return num(f), nil
+
+	case '(':
+		if err := lex.openParen(); err != nil {
+			return nil, err
+		}
+		lex.next() // consume '('
+		lex.opened = true
+
+		// parse expression inside parenthesis
+		e, err := parseExpr(lex)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse the symbol %s: %s", lex, err)
+		}
+
+		if lex.token != ')' {
+			return nil, fmt.Errorf("got %s, want ')'", lex)
+		}
+		lex.closeParen()
+		lex.next() // consume ')'
+
+		return e, nil
+
+	// parse a named constant like pi, a function call f(A, B) or a variable
+	case scanner.Ident:
+		name := lex.text()
+		if _, ok := constants[name]; ok {
+			lex.next() // consume name
+			return constant(name), nil
+		}
+		if _, ok := functions[name]; ok {
+			return parseCall(lex, parseExpr, parseUnary)
+		}
+		lex.next() // consume name
+		if lex.token == '(' {
+			return nil, fmt.Errorf("unknown function %s", name)
+		}
+		return variable(name), nil
+
+	// parse a wildcard ?name of a rewrite rule pattern
+	case '?':
+		if !lex.patterns {
+			break
+		}
+		lex.next() // consume '?'
+		if lex.token != scanner.Ident {
+			return nil, fmt.Errorf("got %s, want name of pattern variable", lex)
+		}
+		w := wildcard(lex.text())
+		lex.next() // consume name
+		return w, nil
+	}
+	return nil, fmt.Errorf("unexpected %s", lex)
+}
@@ -0,0 +1,296 @@
+package expr
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseExponents(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"1e10", 1e10},
+		{"1E10", 1e10},
+		{"1.5E-3", 1.5e-3},
+		{"1.5E+3", 1500},
+		{"2.5e+2 * 1E-2", 2.5},
+	}
+	for _, test := range tests {
+		e := mustParse(t, test.input)
+		if got, err := e.Eval(); err != nil || got != test.want {
+			t.Errorf("Eval(%q) = %v, %v, want %v", test.input, got, err, test.want)
+		}
+	}
+
+	// an exponent needs digits, and a number has only one of them
+	for _, input := range []string{"1E", "1E+", "1.5e3E2"} {
+		if e, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Parse(%q) = %v, want error", input, e)
+		}
+	}
+}
+
+func TestWithDecimalComma(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"3,14 + 10", 13.14},
+		{"-0,5 * 2", -1},
+		{"1,5e2 / (2,5)", 60},
+		{"1.5 + 2", 3.5}, // the point still works
+	}
+	for _, test := range tests {
+		for name, parse := range map[string]func(string) (Expr, error){
+			"Parse":     func(s string) (Expr, error) { return Parse(strings.NewReader(s), WithDecimalComma()) },
+			"EvalParse": func(s string) (Expr, error) { return EvalParse(strings.NewReader(s), WithDecimalComma()) },
+		} {
+			e, err := parse(test.input)
+			if err != nil {
+				t.Fatalf("%s(%q) failed: %v", name, test.input, err)
+			}
+			if got, _ := e.Eval(); got != test.want {
+				t.Errorf("%s(%q) = %v, want %v", name, test.input, got, test.want)
+			}
+		}
+	}
+
+	for _, input := range []string{"3,", "3, 14", "3 ,14", "1,5,3", "1,2.5"} {
+		if e, err := Parse(strings.NewReader(input), WithDecimalComma()); err == nil {
+			t.Errorf("Parse(%q) = %v, want error", input, e)
+		}
+	}
+	if _, err := Parse(strings.NewReader("3,14")); err == nil {
+		t.Error("Parse(\"3,14\") without the option succeeded, want error")
+	}
+
+	e, err := Parse(strings.NewReader("0,1"), WithDecimalComma(), WithSourceLiterals())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := EvalFraction(e); got != "1/10" {
+		t.Errorf("EvalFraction(0,1) = %s, want 1/10", got)
+	}
+}
+
+func TestParseTee(t *testing.T) {
+	input := "  1 + 2 * (3 - 4)\n"
+	e, text, err := ParseTee(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseTee failed: %v", err)
+	}
+	if text != input {
+		t.Errorf("ParseTee captured %q, want %q", text, input)
+	}
+	if got, _ := e.Eval(); got != -1 {
+		t.Errorf("ParseTee parsed %v, want an expression of value -1", e)
+	}
+
+	// a long input, read by the scanner in several chunks
+	long := strings.Repeat("1 + ", 10000) + "1"
+	if _, text, _ := ParseTee(strings.NewReader(long)); text != long {
+		t.Errorf("ParseTee captured %d bytes, want %d", len(text), len(long))
+	}
+
+	// parsing stops at the second ')', well before the end of the input
+	_, text, err = ParseTee(strings.NewReader("(1 + 2)) * 3" + strings.Repeat(" + 4", 1000)))
+	if err == nil {
+		t.Fatal("ParseTee of unbalanced input succeeded, want error")
+	}
+	if text != "(1 + 2))" {
+		t.Errorf("ParseTee captured %q up to the error, want %q", text, "(1 + 2))")
+	}
+}
+
+func TestParseExponentSigns(t *testing.T) {
+	// a sign directly after the e of a number belongs to the exponent
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"3e-2", 0.03},
+		{"1e+5", 1e5},
+		{"3e2-2", 298},
+		{"3e-2-2", 0.03 - 2},
+		{"2e1*-1e-1", -2},
+	}
+	for _, test := range tests {
+		e := mustParse(t, test.input)
+		if got, err := e.Eval(); err != nil || got != test.want {
+			t.Errorf("Eval(%q) = %v, %v, want %v", test.input, got, err, test.want)
+		}
+	}
+
+	// with a space the e is no exponent but an identifier, which is not a number
+	for _, input := range []string{"3 e - 2", "3e -2", "3 e-2"} {
+		if e, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Parse(%q) = %v, want error", input, e)
+		}
+	}
+}
+
+// endlessSum is an endless input "1 + 1 + ...", which cancels a context after a while.
+type endlessSum struct {
+	read   int
+	after  int
+	cancel context.CancelFunc
+}
+
+func (r *endlessSum) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = "1 + "[(r.read+i)%4]
+	}
+	r.read += len(p)
+	if r.read >= r.after {
+		r.cancel()
+	}
+	return len(p), nil
+}
+
+func TestParseContext(t *testing.T) {
+	e, err := ParseContext(context.Background(), strings.NewReader("1 + 2 * 3"))
+	if err != nil {
+		t.Fatalf("ParseContext failed: %v", err)
+	}
+	if got, _ := e.Eval(); got != 7 {
+		t.Errorf("ParseContext parsed %v, want an expression of value 7", e)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &endlessSum{after: 1 << 20, cancel: cancel}
+	if _, err := ParseContext(ctx, r); err != context.Canceled {
+		t.Errorf("ParseContext of a cancelled input returned %v, want %v", err, context.Canceled)
+	}
+	// a check every 1024 tokens of 1 or 2 bytes, and the scanner reads ahead
+	if r.read > 1<<20+8<<10 {
+		t.Errorf("ParseContext read %d bytes, want it to stop soon after %d", r.read, 1<<20)
+	}
+
+	// a context that is already done stops parsing before the first token
+	r = &endlessSum{after: 1 << 20, cancel: cancel}
+	if _, err := ParseContext(ctx, r); err != context.Canceled || r.read != 0 {
+		t.Errorf("ParseContext with a cancelled context returned %v after %d bytes, want %v before reading", err, r.read, context.Canceled)
+	}
+	if _, err := ParseContext(ctx, strings.NewReader("1")); err != context.Canceled {
+		t.Errorf("ParseContext of a short input with a cancelled context returned %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestParsePower(t *testing.T) {
+	tests := []struct {
+		input     string
+		want      float64
+		canonical string
+	}{
+		{"2^3^2", 512, "2 ^ 3 ^ 2"},
+		{"(2^3)^2", 64, "(2 ^ 3) ^ 2"},
+		{"-2^2", -4, "-(2 ^ 2)"},
+		{"(-2)^2", 4, "(-2) ^ 2"},
+		{"2^-1", 0.5, "2 ^ -1"},
+		{"2^-1^2", 0.5, "2 ^ -(1 ^ 2)"},
+		{"2*3^2", 18, "2 * 3 ^ 2"},
+		{"2^(1+1)*3", 12, "2 ^ (1 + 1) * 3"},
+		{"4^0.5", 2, "4 ^ 0.5"},
+	}
+	for _, test := range tests {
+		e := mustParse(t, test.input)
+		if got, err := e.Eval(); err != nil || got != test.want {
+			t.Errorf("Eval(%q) = %v, %v, want %v", test.input, got, err, test.want)
+		}
+		if got := Canonical(e); got != test.canonical {
+			t.Errorf("Canonical(%q) = %q, want %q", test.input, got, test.canonical)
+		}
+		if got := Canonical(mustParse(t, test.canonical)); got != test.canonical {
+			t.Errorf("Canonical does not round-trip: %q parses back as %q", test.canonical, got)
+		}
+
+		ev, err := EvalParse(strings.NewReader(test.input))
+		if err != nil {
+			t.Fatalf("EvalParse(%q) failed: %v", test.input, err)
+		}
+		if got, _ := ev.Eval(); got != test.want {
+			t.Errorf("EvalParse(%q) = %v, want %v", test.input, got, test.want)
+		}
+		h := &stackHandler{}
+		if err := ParseStream(strings.NewReader(test.input), h); err != nil {
+			t.Fatalf("ParseStream(%q) failed: %v", test.input, err)
+		}
+		if len(h.stack) != 1 || h.stack[0] != test.want {
+			t.Errorf("ParseStream(%q) left %v, want [%v]", test.input, h.stack, test.want)
+		}
+	}
+
+	// a built tree with a negative base is written in parentheses
+	if got := Canonical(MustBinary('^', Num(-2), Num(2))); got != "(-2) ^ 2" {
+		t.Errorf("Canonical of a negative base = %q, want (-2) ^ 2", got)
+	}
+}
+
+func TestParseNegativeNumbers(t *testing.T) {
+	tests := []struct {
+		input string
+		len   int
+	}{
+		{"(-5)", 1}, // a signed number alone in parentheses is a number
+		{"(+5)", 1},
+		{"(-5) * 2", 3},
+		{"-(5)", 2},
+		{"(-(5))", 2},
+		{"(-5 * 2)", 4},
+		{"(--5)", 3},
+	}
+	for _, test := range tests {
+		if e := mustParse(t, test.input); e.Len() != test.len {
+			t.Errorf("Len of %q = %d, want %d", test.input, e.Len(), test.len)
+		}
+	}
+
+	// negative numbers built otherwise are written that way and parse back into the same tree
+	e := MustBinary('-', Num(2), MustUnary('-', Num(-0.5)))
+	if got := Canonical(e); got != "2 - -(-0.5)" {
+		t.Errorf("Canonical = %q, want 2 - -(-0.5)", got)
+	}
+	if back := mustParse(t, Canonical(e)); !Same(back, e) {
+		t.Errorf("%s parses back as %v, want the same tree", Canonical(e), back)
+	}
+}
+
+func TestParseModulo(t *testing.T) {
+	tests := []struct {
+		input     string
+		want      float64
+		canonical string
+	}{
+		{"7 % 3", 1, "7 % 3"},
+		{"-7 % 3", -1, "-7 % 3"},
+		{"7.5 % 2", 1.5, "7.5 % 2"},
+		{"2 * 7 % 4", 2, "2 * 7 % 4"},
+		{"7 % 4 * 2", 6, "7 % 4 * 2"},
+		{"7 % (4 * 2)", 7, "7 % (4 * 2)"},
+		{"10 - 7 % 4", 7, "10 - 7 % 4"},
+		{"2 ^ 3 % 3", 2, "2 ^ 3 % 3"},
+	}
+	for _, test := range tests {
+		e := mustParse(t, test.input)
+		if got, err := e.Eval(); err != nil || got != test.want {
+			t.Errorf("Eval(%q) = %v, %v, want %v", test.input, got, err, test.want)
+		}
+		if got := Canonical(e); got != test.canonical {
+			t.Errorf("Canonical(%q) = %q, want %q", test.input, got, test.canonical)
+		}
+		ev, err := EvalParse(strings.NewReader(test.input))
+		if err != nil {
+			t.Fatalf("EvalParse(%q) failed: %v", test.input, err)
+		}
+		if got, _ := ev.Eval(); got != test.want {
+			t.Errorf("EvalParse(%q) = %v, want %v", test.input, got, test.want)
+		}
+	}
+
+	if _, err := mustParse(t, "1 % (2 - 2)").Eval(); err == nil {
+		t.Error("Eval of a modulo by zero succeeded, want error")
+	}
+}
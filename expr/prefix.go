@@ -0,0 +1,44 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// prefixOps holds the unary operators the parser accepts in front of an operand,
+// starting with the signs.
+var prefixOps = map[rune]func(float64) (float64, error){
+	'+': func(x float64) (float64, error) { return +x, nil },
+	'-': func(x float64) (float64, error) { return -x, nil },
+}
+
+// RegisterPrefix registers sym as a prefix operator, evaluated by fn, next to the signs
+// '+' and '-', which are registered from the start. It binds like a sign:
+// with a registered '√', "√9 * 2" is (√9) * 2 and "√(9 * 2)" needs the parentheses.
+// The parsed operations are unary nodes of PrefixKind, and the backends that only know
+// about signs (like EvalRat) report them as unsupported.
+// RegisterPrefix is meant to be called during initialisation, it must not run concurrently
+// with parsing or evaluation. It panics if sym is already an operator, or a rune the
+// parser reserves for something else, like the parentheses, the '?' of wildcards and the
+// runes of numbers and names.
+func RegisterPrefix(sym rune, fn func(float64) (float64, error)) {
+	if prefixOps[sym] != nil || priority(sym) > 0 || isReserved(sym) {
+		panic(fmt.Sprintf("RegisterPrefix: %q is already in use", sym))
+	}
+	if fn == nil {
+		panic("RegisterPrefix: nil function")
+	}
+	prefixOps[sym] = fn
+}
+
+// isReserved reports whether the parser gives r a meaning other than an operator.
+func isReserved(r rune) bool {
+	return strings.ContainsRune("()?@,;._", r) || unicode.IsLetter(r) || unicode.IsDigit(r) ||
+		unicode.IsSpace(r) || !unicode.IsPrint(r)
+}
+
+// isPrefix reports whether op is a registered prefix operator, including the signs.
+func isPrefix(op rune) bool {
+	return prefixOps[op] != nil
+}
@@ -0,0 +1,96 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// registerSqrt registers '√' as the square root for the duration of the test.
+func registerSqrt(t *testing.T) {
+	t.Helper()
+	t.Cleanup(SnapshotRegistry().Restore)
+	RegisterPrefix('√', func(x float64) (float64, error) {
+		if x < 0 {
+			return 0, fmt.Errorf("square root of negative number %v", x)
+		}
+		return math.Sqrt(x), nil
+	})
+}
+
+func TestRegisterPrefix(t *testing.T) {
+	registerSqrt(t)
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"√9", 3},
+		{"√9 * 2", 6},
+		{"√(9 * 4)", 6},
+		{"-√16 + √√16", -2},
+		{"2 * √(3 + 1)", 4},
+	}
+	for _, test := range tests {
+		e := mustParse(t, test.input)
+		if got, err := e.Eval(); err != nil || got != test.want {
+			t.Errorf("Eval(%q) = %v, %v, want %v", test.input, got, err, test.want)
+		}
+		ev, err := EvalParse(strings.NewReader(test.input))
+		if err != nil {
+			t.Fatalf("EvalParse(%q) failed: %v", test.input, err)
+		}
+		if got, _ := ev.Eval(); got != test.want {
+			t.Errorf("EvalParse(%q) = %v, want %v", test.input, got, test.want)
+		}
+	}
+
+	if _, err := mustParse(t, "√(1 - 2)").Eval(); err == nil {
+		t.Error("square root of -1 succeeded, want error")
+	}
+}
+
+func TestRegisterPrefixInUse(t *testing.T) {
+	registerSqrt(t)
+	for _, sym := range []rune{'-', '*', '(', '√', '?', ',', '.', '7', 'x', ' '} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterPrefix(%q) did not panic", sym)
+				}
+			}()
+			RegisterPrefix(sym, func(x float64) (float64, error) { return x, nil })
+		}()
+	}
+}
+
+func TestRegisterPrefixLogicalNot(t *testing.T) {
+	t.Cleanup(SnapshotRegistry().Restore)
+	RegisterPrefix('!', func(x float64) (float64, error) {
+		if x == 0 {
+			return 1, nil
+		}
+		return 0, nil
+	})
+
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"!0", 1},
+		{"!5 + !!5", 1},
+		{"-!(2 - 2) * 3", -3},
+	}
+	for _, test := range tests {
+		if got, err := mustParse(t, test.input).Eval(); err != nil || got != test.want {
+			t.Errorf("Eval(%q) = %v, %v, want %v", test.input, got, err, test.want)
+		}
+	}
+
+	// the signs are registered like any other prefix operator
+	for _, sign := range []rune{'+', '-'} {
+		if !isPrefix(sign) {
+			t.Errorf("sign %q is not a registered prefix operator", sign)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package expr
+
+import "fmt"
+
+// Prune removes the subexpression at the given path and returns the modified tree.
+// A path lists operand indices starting from the root: 0 is the operand of a unary or
+// the left operand of a binary, 1 is the right operand of a binary, and i is the argument
+// i of a call. For instance, [0, 1] is the right operand of the left operand of the root.
+// Removing an operand of a binary leaves the other operand in place of the binary;
+// removing the operand of a unary removes the unary too. Removing an argument of a call
+// to a function like min that takes any number of them leaves the call with the others;
+// removing the argument of any other call removes the call too, as it could not be
+// evaluated without it. The root itself cannot be removed.
+func Prune(e Expr, path []int) (Expr, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot prune the root of the expression")
+	}
+	pruned, err := prune(e, path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %v: %s", path, err)
+	}
+	if pruned == nil {
+		return nil, fmt.Errorf("pruning path %v would remove the whole expression", path)
+	}
+	return pruned, nil
+}
+
+// prune returns the expression e without the subtree at path, or nil when nothing remains of e.
+func prune(e Expr, path []int) (Expr, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	i, rest := path[0], path[1:]
+
+	switch e := e.(type) {
+	case unary:
+		if i != 0 {
+			return nil, fmt.Errorf("unary %v has no operand %d", e, i)
+		}
+		x, err := prune(e.x, rest)
+		if x == nil || err != nil {
+			return nil, err
+		}
+		return unary{e.op, x}, nil
+
+	case binary:
+		var x, y Expr
+		var err error
+		switch i {
+		case 0:
+			x, err = prune(e.x, rest)
+			y = e.y
+		case 1:
+			x = e.x
+			y, err = prune(e.y, rest)
+		default:
+			return nil, fmt.Errorf("binary %v has no operand %d", e, i)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if x == nil {
+			return y, nil
+		}
+		if y == nil {
+			return x, nil
+		}
+		return binary{e.op, x, y}, nil
+
+	case call:
+		if i < 0 || i >= len(e.args) {
+			return nil, fmt.Errorf("call %v has no argument %d", e, i)
+		}
+		x, err := prune(e.args[i], rest)
+		if err != nil {
+			return nil, err
+		}
+		args := append([]Expr(nil), e.args...)
+		if x != nil {
+			args[i] = x
+			return call{e.name, args}, nil
+		}
+		args = append(args[:i], args[i+1:]...)
+		if checkArity(e.name, len(args)) != nil {
+			return nil, nil // the call cannot do without the argument
+		}
+		return call{e.name, args}, nil
+	}
+	return nil, fmt.Errorf("%v has no operands", e)
+}
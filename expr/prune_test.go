@@ -0,0 +1,58 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		input string
+		path  []int
+		want  string
+	}{
+		{"1 + 2", []int{0}, "2.00"},
+		{"1 + 2", []int{1}, "1.00"},
+		{"1 * 2 + 3", []int{0, 1}, "1.00 + 3.00"},
+		{"-(1 * 2) + 3", []int{0, 0, 0}, "-2.00 + 3.00"},
+		{"-4 + 3", []int{0, 0}, "3.00"}, // the unary goes along with its operand
+		{"max(1, 2, 3)", []int{1}, "max(1.00, 3.00)"},
+		{"max(1, 2 * 3)", []int{1, 0}, "max(1.00, 3.00)"},
+		{"sqrt(4) + 1", []int{0, 0}, "1.00"}, // the call goes along with its argument
+		{"max(1) + 2", []int{0, 0}, "2.00"},
+	}
+	for _, test := range tests {
+		e, err := Parse(strings.NewReader(test.input))
+		if err != nil {
+			t.Fatalf("could not parse %q: %v", test.input, err)
+		}
+		got, err := Prune(e, test.path)
+		if err != nil {
+			t.Fatalf("Prune(%q, %v) failed: %v", test.input, test.path, err)
+		}
+		if got.String() != test.want {
+			t.Errorf("Prune(%q, %v) = %q, want %q", test.input, test.path, got, test.want)
+		}
+	}
+}
+
+func TestPruneInvalidPath(t *testing.T) {
+	e, _ := Parse(strings.NewReader("-(1 * 2) + 3"))
+	for _, path := range [][]int{
+		{},           // the root
+		{2},          // binary has two operands
+		{0, 1},       // unary has one operand
+		{1, 0},       // a number has no operands
+		{0, 0, 0, 0}, // too deep
+	} {
+		if _, err := Prune(e, path); err == nil {
+			t.Errorf("Prune(%v, %v) succeeded, want error", e, path)
+		}
+	}
+	if _, err := Prune(mustParse(t, "-1"), []int{0}); err == nil {
+		t.Error("Prune removed the whole expression, want error")
+	}
+	if _, err := Prune(mustParse(t, "atan2(1, 2)"), []int{2}); err == nil {
+		t.Error("Prune of a missing argument succeeded, want error")
+	}
+}
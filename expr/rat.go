@@ -0,0 +1,137 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// EvalRat evaluates the expression exactly, in rational arithmetic.
+// Numbers are taken by their shortest decimal representation, so 0.1 is exactly 1/10
+// and not the binary approximation stored in the float. Numbers parsed with
+// WithSourceLiterals are taken exactly as written.
+func EvalRat(e Expr) (*big.Rat, error) {
+	switch e := e.(type) {
+	case constant:
+		return nil, fmt.Errorf("constant %s is irrational", string(e))
+	case num:
+		r, ok := new(big.Rat).SetString(FormatCanonical(float64(e)))
+		if !ok {
+			return nil, fmt.Errorf("number %v is not rational", e)
+		}
+		return r, nil
+
+	case literal:
+		if r, ok := new(big.Rat).SetString(e.text); ok {
+			return r, nil
+		}
+		return EvalRat(e.num)
+
+	case unary:
+		x, err := EvalRat(e.x)
+		if err != nil {
+			return nil, err
+		}
+		switch e.op {
+		case '+':
+			return x, nil
+		case '-':
+			return x.Neg(x), nil
+		}
+		return nil, fmt.Errorf("unsupported unary operator: %q", e.op)
+
+	case binary:
+		x, err := EvalRat(e.x)
+		if err != nil {
+			return nil, err
+		}
+		y, err := EvalRat(e.y)
+		if err != nil {
+			return nil, err
+		}
+		switch e.op {
+		case '+':
+			return x.Add(x, y), nil
+		case '-':
+			return x.Sub(x, y), nil
+		case '*':
+			return x.Mul(x, y), nil
+		case '/':
+			if y.Sign() == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return x.Quo(x, y), nil
+		case '%':
+			if y.Sign() == 0 {
+				return nil, fmt.Errorf("modulo by zero")
+			}
+			return ratMod(x, y), nil
+		case '^':
+			return ratPow(x, y)
+		}
+		return nil, fmt.Errorf("unsupported binary operator: %q", e.op)
+	}
+	return nil, fmt.Errorf("cannot evaluate %v exactly", e)
+}
+
+// ratMod returns the remainder of x / y truncated towards zero, like math.Mod:
+// it has the sign of x and a smaller magnitude than y.
+func ratMod(x, y *big.Rat) *big.Rat {
+	q := new(big.Rat).Quo(x, y)
+	n := new(big.Int).Quo(q.Num(), q.Denom()) // truncated towards zero
+	return x.Sub(x, new(big.Rat).Mul(new(big.Rat).SetInt(n), y))
+}
+
+// maxExactBits bounds the size of the powers computed by the exact evaluators: 2 ^ 65535
+// already has that many bits. Bounding the exponent alone would not do, as the base of a
+// power may be a power itself: (10 ^ 16384) ^ 16384 would exhaust the memory.
+const maxExactBits = 1 << 16
+
+// ratPow returns x ^ y for an integer y for which the numerator and denominator of the
+// result have at most maxExactBits. Any other exponent would make the result irrational
+// or too large.
+func ratPow(x, y *big.Rat) (*big.Rat, error) {
+	n, err := exactExponent(y, max(x.Num().BitLen(), x.Denom().BitLen()))
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 {
+		if x.Sign() == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		x.Inv(x)
+		n = -n
+	}
+	exp := big.NewInt(n)
+	num := new(big.Int).Exp(x.Num(), exp, nil)
+	den := new(big.Int).Exp(x.Denom(), exp, nil)
+	return x.SetFrac(num, den), nil
+}
+
+// exactExponent returns y as an exponent for the exact evaluators, of a base whose
+// numerator and denominator have at most baseBits. The power has at most baseBits
+// times the exponent bits, which must not exceed maxExactBits unless the base is 0, 1
+// or -1, whose powers do not grow.
+func exactExponent(y *big.Rat, baseBits int) (int64, error) {
+	if !y.IsInt() {
+		return 0, fmt.Errorf("exponent %s is not an integer", y.RatString())
+	}
+	limit := big.NewInt(math.MaxInt64)
+	if baseBits > 1 {
+		limit.SetInt64(int64(maxExactBits / baseBits))
+	}
+	if y.Num().CmpAbs(limit) > 0 {
+		return 0, fmt.Errorf("power with exponent %s would have more than %d bits", y.RatString(), maxExactBits)
+	}
+	return y.Num().Int64(), nil
+}
+
+// EvalFraction evaluates the expression exactly and returns the result as a reduced
+// fraction like "22/7", or as an integer like "3" when the denominator is 1.
+func EvalFraction(e Expr) (string, error) {
+	r, err := EvalRat(e)
+	if err != nil {
+		return "", err
+	}
+	return r.RatString(), nil
+}
@@ -0,0 +1,80 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvalFraction(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"1/3 + 1/6", "1/2"},
+		{"22/7", "22/7"},
+		{"0.1 + 0.2", "3/10"},
+		{"-(1/3) * 3", "-1"},
+		{"2.5 * 4 - 1", "9"},
+		{"(2/3) ^ 3", "8/27"},
+		{"2 ^ -2 + 0.1 ^ 2", "13/50"},
+		{"7.5 % 2", "3/2"},
+		{"-(1/3) % (1/4)", "-1/12"},
+	}
+	for _, test := range tests {
+		e, err := Parse(strings.NewReader(test.input))
+		if err != nil {
+			t.Fatalf("could not parse %q: %v", test.input, err)
+		}
+		got, err := EvalFraction(e)
+		if err != nil {
+			t.Fatalf("EvalFraction(%q) failed: %v", test.input, err)
+		}
+		if got != test.want {
+			t.Errorf("EvalFraction(%q) = %s, want %s", test.input, got, test.want)
+		}
+	}
+}
+
+func TestEvalFractionDivisionByZero(t *testing.T) {
+	e, _ := Parse(strings.NewReader("1 / (1/3 - 2/6)"))
+	if _, err := EvalFraction(e); err == nil {
+		t.Error("EvalFraction succeeded, want division by zero error")
+	}
+}
+
+func TestEvalFractionLargePowers(t *testing.T) {
+	for _, input := range []string{"(1/2) ^ 100000", "((2/3) ^ 1000) ^ 1000", "2 ^ 2 ^ 2 ^ 2 ^ 2"} {
+		if _, err := EvalFraction(mustParse(t, input)); err == nil {
+			t.Errorf("EvalFraction(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestSourceLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		str      string
+		fraction string
+	}{
+		{"0.1", "0.1", "1/10"},
+		{"0.1 + 0.2", "0.1 + 0.2", "3/10"},
+		{"12345678901234567890123 * 10", "12345678901234567890123 * 10", "123456789012345678901230"},
+		{"1.50 / 3", "1.50 / 3", "1/2"},
+	}
+	for _, test := range tests {
+		e, err := Parse(strings.NewReader(test.input), WithSourceLiterals())
+		if err != nil {
+			t.Fatalf("could not parse %q: %v", test.input, err)
+		}
+		if got := e.String(); got != test.str {
+			t.Errorf("String of %q = %s, want %s", test.input, got, test.str)
+		}
+		got, err := EvalFraction(e)
+		if err != nil {
+			t.Fatalf("EvalFraction(%q) failed: %v", test.input, err)
+		}
+		if got != test.fraction {
+			t.Errorf("EvalFraction(%q) = %s, want %s", test.input, got, test.fraction)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package expr
+
+import "maps"
+
+// A Registry is a copy of the functions and prefix operators registered with
+// RegisterFunction and RegisterPrefix, taken by SnapshotRegistry.
+type Registry struct {
+	functions map[string]function
+	prefixOps map[rune]func(float64) (float64, error)
+}
+
+// SnapshotRegistry returns a copy of the registered functions and prefix operators, so
+// that registrations made for a test or a sandboxed evaluation can be rolled back:
+//
+//	defer expr.SnapshotRegistry().Restore()
+//
+// Like the registration functions, it must not run concurrently with them.
+func SnapshotRegistry() Registry {
+	return Registry{maps.Clone(functions), maps.Clone(prefixOps)}
+}
+
+// Restore makes the registered functions and prefix operators those of the snapshot,
+// dropping everything registered since. A snapshot can be restored more than once.
+// Like the registration functions, it must not run concurrently with parsing or evaluation.
+func (r Registry) Restore() {
+	functions = maps.Clone(r.functions)
+	prefixOps = maps.Clone(r.prefixOps)
+}
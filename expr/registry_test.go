@@ -0,0 +1,32 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSnapshotRegistry(t *testing.T) {
+	snapshot := SnapshotRegistry()
+	RegisterFunction("twice", 1, func(args []float64) (float64, error) { return 2 * args[0], nil })
+	RegisterPrefix('~', func(x float64) (float64, error) { return -x, nil })
+	if got, err := mustParse(t, "twice(~3)").Eval(); err != nil || got != -6 {
+		t.Fatalf("twice(~3) = %v, %v, want -6", got, err)
+	}
+
+	snapshot.Restore()
+	for _, input := range []string{"twice(3)", "~3"} {
+		if _, err := Parse(strings.NewReader(input)); err == nil {
+			t.Errorf("Parse(%q) after Restore succeeded, want error", input)
+		}
+	}
+	if got, err := mustParse(t, "sqrt(9)").Eval(); err != nil || got != 3 {
+		t.Errorf("sqrt(9) = %v, %v after Restore, want the built-in 3", got, err)
+	}
+
+	// the snapshot still holds after a restore, and the names are free again
+	RegisterFunction("twice", 1, func(args []float64) (float64, error) { return args[0], nil })
+	snapshot.Restore()
+	if _, err := Parse(strings.NewReader("twice(3)")); err == nil {
+		t.Error("Parse(\"twice(3)\") after a second Restore succeeded, want error")
+	}
+}
@@ -0,0 +1,226 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+	"text/scanner"
+)
+
+// A Rule rewrites any subexpression matching Pattern into Replacement.
+// Patterns can contain wildcards (written ?x in text form) that match any subtree;
+// the subtrees bound by the wildcards are substituted into the replacement.
+// A wildcard used twice in a pattern only matches identical subtrees.
+type Rule struct {
+	Pattern     Expr
+	Replacement Expr
+}
+
+func (r Rule) String() string {
+	return fmt.Sprintf("%v -> %v", r.Pattern, r.Replacement)
+}
+
+// A wildcard is a pattern variable; it only exists within rules and cannot be evaluated.
+type wildcard string
+
+func (w wildcard) Eval() (float64, error) {
+	return 0, fmt.Errorf("cannot evaluate pattern variable ?%s", string(w))
+}
+func (w wildcard) String() string {
+	return "?" + string(w)
+}
+func (w wildcard) Len() int {
+	return 1
+}
+func (w wildcard) Cost() int {
+	return 1
+}
+
+// ParseRule parses a rule in the form "pattern -> replacement", e.g. "?x + 0 -> ?x".
+// Every wildcard in the replacement has to appear in the pattern.
+func ParseRule(s string) (Rule, error) {
+	pattern, replacement, ok := strings.Cut(s, "->")
+	if !ok {
+		return Rule{}, fmt.Errorf("missing '->' in rule %q", s)
+	}
+	p, err := parsePattern(pattern)
+	if err != nil {
+		return Rule{}, fmt.Errorf("could not parse pattern of rule %q: %s", s, err)
+	}
+	r, err := parsePattern(replacement)
+	if err != nil {
+		return Rule{}, fmt.Errorf("could not parse replacement of rule %q: %s", s, err)
+	}
+
+	bound := make(map[wildcard]bool)
+	Walk(p, func(e Expr) bool {
+		if w, ok := e.(wildcard); ok {
+			bound[w] = true
+		}
+		return true
+	})
+	var unbound error
+	Walk(r, func(e Expr) bool {
+		if w, ok := e.(wildcard); ok && !bound[w] && unbound == nil {
+			unbound = fmt.Errorf("pattern variable %s of rule %q not bound by the pattern", w, s)
+		}
+		return true
+	})
+	if unbound != nil {
+		return Rule{}, unbound
+	}
+	return Rule{p, r}, nil
+}
+
+// MustParseRule is like ParseRule but panics if the rule cannot be parsed.
+// It is meant for rules defined as package-level variables.
+func MustParseRule(s string) Rule {
+	r, err := ParseRule(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// parsePattern parses an expression in which ?name wildcards are allowed as primaries.
+func parsePattern(s string) (Expr, error) {
+	lex := newLexer(strings.NewReader(s), nil)
+	lex.patterns = true
+
+	lex.next() // initial lookahead
+	e, err := parseExpr(lex)
+	if err != nil {
+		return nil, err
+	}
+	if lex.token != scanner.EOF {
+		return nil, fmt.Errorf("unexpected %s", lex)
+	}
+	return e, nil
+}
+
+// Rewrite applies the rules to every subexpression of e, operands first, and repeats
+// until no rule matches anymore. The rules are tried in order at each node.
+// Rules that undo each other (like ?x + ?y -> ?y + ?x) never reach a fixpoint,
+// it is up to the caller to provide a terminating set of rules.
+func Rewrite(e Expr, rules []Rule) Expr {
+	for {
+		var changed bool
+		e, changed = rewriteOnce(e, rules)
+		if !changed {
+			return e
+		}
+	}
+}
+
+// rewriteOnce rewrites the operands of e and then e itself with the first matching rule.
+func rewriteOnce(e Expr, rules []Rule) (Expr, bool) {
+	changed := false
+	switch n := e.(type) {
+	case unary:
+		x, ok := rewriteOnce(n.x, rules)
+		if ok {
+			e, changed = unary{n.op, x}, true
+		}
+	case binary:
+		x, okx := rewriteOnce(n.x, rules)
+		y, oky := rewriteOnce(n.y, rules)
+		if okx || oky {
+			e, changed = binary{n.op, x, y}, true
+		}
+	case call:
+		args := make([]Expr, len(n.args))
+		for i, a := range n.args {
+			var ok bool
+			args[i], ok = rewriteOnce(a, rules)
+			changed = changed || ok
+		}
+		if changed {
+			e = call{n.name, args}
+		}
+	}
+
+	for _, r := range rules {
+		bindings := make(map[wildcard]Expr)
+		if match(r.Pattern, e, bindings) {
+			return substitute(r.Replacement, bindings), true
+		}
+	}
+	return e, changed
+}
+
+// match reports whether e has the shape of the pattern, binding the wildcards on the way.
+func match(pattern, e Expr, bindings map[wildcard]Expr) bool {
+	switch p := pattern.(type) {
+	case wildcard:
+		if bound, ok := bindings[p]; ok {
+			return equalExpr(bound, e)
+		}
+		bindings[p] = e
+		return true
+	case num:
+		n, ok := numValue(e)
+		return ok && n == p
+	case unary:
+		u, ok := e.(unary)
+		return ok && u.op == p.op && match(p.x, u.x, bindings)
+	case binary:
+		b, ok := e.(binary)
+		return ok && b.op == p.op && match(p.x, b.x, bindings) && match(p.y, b.y, bindings)
+	case call:
+		c, ok := e.(call)
+		if !ok || c.name != p.name || len(c.args) != len(p.args) {
+			return false
+		}
+		for i := range p.args {
+			if !match(p.args[i], c.args[i], bindings) {
+				return false
+			}
+		}
+		return true
+	case variable, constant:
+		return e == p
+	}
+	return false
+}
+
+// substitute builds the replacement, replacing the wildcards by their bound subtrees.
+func substitute(template Expr, bindings map[wildcard]Expr) Expr {
+	switch t := template.(type) {
+	case wildcard:
+		return bindings[t]
+	case unary:
+		return unary{t.op, substitute(t.x, bindings)}
+	case binary:
+		return binary{t.op, substitute(t.x, bindings), substitute(t.y, bindings)}
+	case call:
+		args := make([]Expr, len(t.args))
+		for i, a := range t.args {
+			args[i] = substitute(a, bindings)
+		}
+		return call{t.name, args}
+	}
+	return template
+}
+
+// ToAdditiveMultiplicative returns an equivalent expression in which every subtraction
+// a - b is an addition a + -b and every division a / b a multiplication a * (1 / b).
+// The only binary operators left are the associative and commutative '+' and '*',
+// apart from the reciprocals 1 / b, which still fail to evaluate when b is zero,
+// and the remainders and powers, which are kept as they are.
+func ToAdditiveMultiplicative(e Expr) Expr {
+	return ReplaceFunc(e, func(e Expr) (Expr, bool) {
+		b, ok := e.(binary)
+		if !ok {
+			return nil, false
+		}
+		switch b.op {
+		case '-':
+			return binary{'+', b.x, unary{'-', b.y}}, true
+		case '/':
+			if n, ok := numValue(b.x); ok && n == 1 {
+				return nil, false // already a reciprocal
+			}
+			return binary{'*', b.x, binary{'/', num(1), b.y}}, true
+		}
+		return nil, false
+	})
+}
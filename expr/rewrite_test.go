@@ -0,0 +1,82 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRewriteToFixpoint(t *testing.T) {
+	rules := []Rule{
+		MustParseRule("?x + 0 -> ?x"),
+		MustParseRule("?x * 1 -> ?x"),
+		MustParseRule("?x - ?x -> 0"),
+		MustParseRule("sqrt(?x) ^ 2 -> ?x"),
+	}
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"(3 + 0) * 1 + 0", "3.00"},
+		{"(1 + 2) - (1 + 2) + 5", "0.00 + 5.00"},
+		{"(1 + 2) - (2 + 1)", "1.00 + 2.00 - 2.00 + 1.00"}, // not identical subtrees
+		{"((4 - 4) + 0) * 1", "0.00"},                      // needs several passes
+		{"sqrt(2) - sqrt(2)", "0.00"},
+		{"sqrt(3 * 1 + 0)", "sqrt(3.00)"},
+		{"sqrt(5) ^ 2 + 1", "5.00 + 1.00"},
+	}
+	for _, test := range tests {
+		e, err := Parse(strings.NewReader(test.input))
+		if err != nil {
+			t.Fatalf("could not parse %q: %v", test.input, err)
+		}
+		if got := Rewrite(e, rules).String(); got != test.want {
+			t.Errorf("Rewrite(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	for _, rule := range []string{
+		"?x + 0",       // no arrow
+		"?x + 0 -> ?y", // unbound replacement variable
+		"? + 0 -> 0",   // missing variable name
+	} {
+		if _, err := ParseRule(rule); err == nil {
+			t.Errorf("ParseRule(%q) succeeded, want error", rule)
+		}
+	}
+}
+
+func TestParseRejectsWildcards(t *testing.T) {
+	if _, err := Parse(strings.NewReader("?x + 1")); err == nil {
+		t.Error("Parse accepted a pattern variable outside of a rule")
+	}
+}
+
+func TestToAdditiveMultiplicative(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"1 - 2", "1 + -2"},
+		{"6 / 3", "6 * (1 / 3)"},
+		{"1 / 4", "1 / 4"},
+		{"(8 - 2) / (4 - 1) - -5", "(8 + -2) * (1 / (4 + -1)) + --5"},
+		{"7 % (5 - 2)", "7 % (5 + -2)"}, // remainders are kept
+	}
+	for _, test := range tests {
+		e := mustParse(t, test.input)
+		got := ToAdditiveMultiplicative(e)
+		if Canonical(got) != test.want {
+			t.Errorf("ToAdditiveMultiplicative(%q) = %q, want %q", test.input, Canonical(got), test.want)
+		}
+		want, _ := e.Eval()
+		if v, err := got.Eval(); err != nil || v != want {
+			t.Errorf("rewritten %q evaluates to %v, %v, want %v", test.input, v, err, want)
+		}
+	}
+
+	if _, err := ToAdditiveMultiplicative(mustParse(t, "1 / 0")).Eval(); err == nil {
+		t.Error("rewritten 1 / 0 evaluates without error")
+	}
+}
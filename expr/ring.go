@@ -1,7 +1,8 @@
-package main
+package expr
 
 import (
 	"fmt"
+	"math"
 )
 
 // A num is a floating number
@@ -16,10 +17,36 @@ func (f num) String() string {
 func (f num) Len() int {
 	return 1
 }
+func (f num) Cost() int {
+	return 1
+}
+
+// A literal is a number that keeps the text it was parsed from, like "0.1".
+// It prints as written and lets the exact backends use the decimal source
+// instead of the binary float. Parse only produces it with WithSourceLiterals.
+type literal struct {
+	num
+	text string
+}
+
+func (l literal) String() string {
+	return l.text
+}
+
+// numValue returns the value of a number node, whether or not it keeps its source text.
+func numValue(e Expr) (num, bool) {
+	switch e := e.(type) {
+	case num:
+		return e, true
+	case literal:
+		return e.num, true
+	}
+	return 0, false
+}
 
 // A unary is an operator with only one operand
 type unary struct {
-	op rune // one of '+', '-'
+	op rune // one of '+', '-' or a registered prefix operator
 	x  Expr
 }
 
@@ -38,6 +65,10 @@ func (u unary) Eval() (float64, error) {
 	case '-':
 		return -x, nil
 	}
+	// the signs above are the common case, they skip the lookup
+	if fn, ok := prefixOps[u.op]; ok {
+		return fn(x)
+	}
 	return 0, fmt.Errorf("unsupported unary operator: %q", u.op)
 }
 
@@ -45,9 +76,13 @@ func (u unary) Len() int {
 	return u.x.Len() + 1
 }
 
+func (u unary) Cost() int {
+	return u.x.Cost() + opCost(u.op)
+}
+
 // A binary is an operator with two operands
 type binary struct {
-	op   rune // one of '+', '-', '*', '/'
+	op   rune // one of '+', '-', '*', '/', '%', '^'
 	x, y Expr
 }
 
@@ -76,6 +111,13 @@ func (b binary) Eval() (float64, error) {
 			return 0, fmt.Errorf("division by zero")
 		}
 		return x / y, nil
+	case '%':
+		if y == 0 {
+			return 0, fmt.Errorf("modulo by zero")
+		}
+		return math.Mod(x, y), nil
+	case '^':
+		return math.Pow(x, y), nil
 	default:
 		return 0, fmt.Errorf("unsupported binary operator: %q", b.op)
 	}
@@ -84,3 +126,7 @@ func (b binary) Eval() (float64, error) {
 func (b binary) Len() int {
 	return b.x.Len() + b.y.Len() + 1
 }
+
+func (b binary) Cost() int {
+	return b.x.Cost() + b.y.Cost() + opCost(b.op)
+}
@@ -0,0 +1,44 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+)
+
+// EvalWithRecover evaluates the expression like Eval, but turns a panic during the
+// evaluation into an error, so that evaluating an arbitrary tree never crashes the
+// process. This matters for trees not built by Parse, e.g. with custom Expr nodes.
+func EvalWithRecover(e Expr) (result float64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = 0, fmt.Errorf("evaluation panicked: %v", r)
+		}
+	}()
+	return e.Eval()
+}
+
+// EvalFinite evaluates the expression like Eval, but fails if any operation or function
+// call overflows to an infinity or produces NaN, even if a later operation brings the
+// result back into range, like 1 / (1e308 * 10) or 1 / exp(1000), which Eval computes as 0.
+func EvalFinite(e Expr) (float64, error) {
+	var bad error
+	res, err := evalHooked(e, func(op rune, x, y, result float64) {
+		if bad == nil && (math.IsInf(result, 0) || math.IsNaN(result)) {
+			bad = fmt.Errorf("%g %c %g is not finite", x, op, y)
+		}
+	}, func(c call, args []float64, result float64) {
+		if bad == nil && (math.IsInf(result, 0) || math.IsNaN(result)) {
+			bad = fmt.Errorf("%s is not finite", formatCall(c.name, args))
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	if bad != nil {
+		return 0, bad
+	}
+	if math.IsInf(res, 0) || math.IsNaN(res) {
+		return 0, fmt.Errorf("result %g is not finite", res)
+	}
+	return res, nil
+}
@@ -0,0 +1,43 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+// panicky is a custom node whose evaluation panics.
+type panicky struct{}
+
+func (panicky) Eval() (float64, error) { panic("broken custom node") }
+func (panicky) String() string         { return "panicky" }
+func (panicky) Len() int               { return 1 }
+func (panicky) Cost() int              { return 1 }
+
+func TestEvalWithRecover(t *testing.T) {
+	_, err := EvalWithRecover(binary{'+', num(1), unary{'-', panicky{}}})
+	if err == nil {
+		t.Fatal("EvalWithRecover succeeded on a panicking node, want error")
+	}
+	if !strings.Contains(err.Error(), "broken custom node") {
+		t.Errorf("error %q does not mention the recovered value", err)
+	}
+
+	got, err := EvalWithRecover(mustParse(t, "1 + 2"))
+	if err != nil || got != 3 {
+		t.Errorf("EvalWithRecover(1 + 2) = %g, %v; want 3, nil", got, err)
+	}
+	if _, err := EvalWithRecover(mustParse(t, "1 / 0")); err == nil {
+		t.Error("EvalWithRecover(1 / 0) succeeded, want division by zero error")
+	}
+}
+
+func TestEvalFinite(t *testing.T) {
+	if got, err := EvalFinite(mustParse(t, "1e300 * 4 / 8")); err != nil || got != 5e299 {
+		t.Errorf("EvalFinite of a finite computation = %v, %v, want 5e299", got, err)
+	}
+	for _, input := range []string{"1e308 * 10", "1 / (1e308 * 10)", "1e308 * 10 - 1e308 * 10", "1 / 0", "1 / exp(1000)", "1 + 0 * sqrt(-1)", "sqrt(1e308 * 10)"} {
+		if got, err := EvalFinite(mustParse(t, input)); err == nil {
+			t.Errorf("EvalFinite(%q) = %v, want error", input, got)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package expr
+
+import "cmp"
+
+// Same reports whether a and b are the same expression up to the order of the operands
+// of the commutative operators '+' and '*'. So 1 + 2 is the same as 2 + 1, but 1 - 2
+// is not the same as 2 - 1. Associativity is not taken into account: (1 + 2) + 3 is not
+// the same as 1 + (2 + 3).
+func Same(a, b Expr) bool {
+	return equalExpr(commutativeOrder(a), commutativeOrder(b))
+}
+
+// equalExpr reports whether a and b are identical trees. Unlike ==, it does not panic on
+// calls, whose argument slices make them incomparable.
+func equalExpr(a, b Expr) bool {
+	switch a := a.(type) {
+	case unary:
+		b, ok := b.(unary)
+		return ok && a.op == b.op && equalExpr(a.x, b.x)
+	case binary:
+		b, ok := b.(binary)
+		return ok && a.op == b.op && equalExpr(a.x, b.x) && equalExpr(a.y, b.y)
+	case call:
+		b, ok := b.(call)
+		if !ok || a.name != b.name || len(a.args) != len(b.args) {
+			return false
+		}
+		for i := range a.args {
+			if !equalExpr(a.args[i], b.args[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	// numbers, literals, variables, wildcards and nodes not built by Parse
+	return a == b
+}
+
+// commutativeOrder returns a copy of e in which the operands of every commutative
+// operation are sorted, so that trees differing only in that order become identical.
+func commutativeOrder(e Expr) Expr {
+	switch e := e.(type) {
+	case literal:
+		return e.num // the source text does not matter
+	case unary:
+		return unary{e.op, commutativeOrder(e.x)}
+	case binary:
+		x, y := commutativeOrder(e.x), commutativeOrder(e.y)
+		if (e.op == '+' || e.op == '*') && compareExpr(y, x) < 0 {
+			x, y = y, x
+		}
+		return binary{e.op, x, y}
+	case call:
+		args := make([]Expr, len(e.args))
+		for i, a := range e.args {
+			args[i] = commutativeOrder(a)
+		}
+		return call{e.name, args}
+	}
+	return e
+}
+
+// compareExpr defines an arbitrary but total order over expressions: numbers come
+// before constants, constants before variables, variables before unaries, unaries
+// before binaries and binaries before calls. Nodes of the same kind are compared by value, name or operator first
+// and then by their operands or arguments.
+func compareExpr(a, b Expr) int {
+	if c := cmp.Compare(kindRank(a), kindRank(b)); c != 0 {
+		return c
+	}
+	switch a := a.(type) {
+	case num:
+		return cmp.Compare(a, b.(num))
+	case constant:
+		return cmp.Compare(a, b.(constant))
+	case variable:
+		return cmp.Compare(a, b.(variable))
+	case unary:
+		b := b.(unary)
+		if c := cmp.Compare(a.op, b.op); c != 0 {
+			return c
+		}
+		return compareExpr(a.x, b.x)
+	case binary:
+		b := b.(binary)
+		if c := cmp.Compare(a.op, b.op); c != 0 {
+			return c
+		}
+		if c := compareExpr(a.x, b.x); c != 0 {
+			return c
+		}
+		return compareExpr(a.y, b.y)
+	case call:
+		b := b.(call)
+		if c := cmp.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(len(a.args), len(b.args)); c != 0 {
+			return c
+		}
+		for i := range a.args {
+			if c := compareExpr(a.args[i], b.args[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+func kindRank(e Expr) int {
+	switch e.(type) {
+	case num:
+		return 0
+	case constant:
+		return 1
+	case variable:
+		return 2
+	case unary:
+		return 3
+	case binary:
+		return 4
+	case call:
+		return 5
+	}
+	return 6
+}
@@ -0,0 +1,37 @@
+package expr
+
+import "testing"
+
+func TestSame(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1 + 2", "2 + 1", true},
+		{"2 * 3", "3 * 2", true},
+		{"(1 + 2) * -3", "-3 * (2 + 1)", true},
+		{"1 + 2 * 3", "3 * 2 + 1", true},
+		{"1 - 2", "2 - 1", false},
+		{"1 / 2", "2 / 1", false},
+		{"1 + 2", "1 * 2", false},
+		{"1 + 2 + 3", "1 + (2 + 3)", false}, // different association
+		{"1 + 2", "1 + 2.0001", false},
+		{"sqrt(2)", "sqrt(2)", true},
+		{"sqrt(2) + 1", "1 + sqrt(2)", true},
+		{"max(1 + 2, 3)", "max(2 + 1, 3)", true},
+		{"sqrt(2)", "sqrt(3)", false},
+		{"min(1, 2)", "max(1, 2)", false},
+		{"a + b", "b + a", true},
+		{"a * b + c", "c + b * a", true},
+		{"a - b", "b - a", false},
+		{"a + b", "a + c", false},
+		{"sqrt(a) + sqrt(b)", "sqrt(b) + sqrt(a)", true},
+		{"max(1, 2) * max(1)", "max(1) * max(1, 2)", true},
+	}
+	for _, test := range tests {
+		a, b := mustParse(t, test.a), mustParse(t, test.b)
+		if got := Same(a, b); got != test.want {
+			t.Errorf("Same(%q, %q) = %t, want %t", test.a, test.b, got, test.want)
+		}
+	}
+}
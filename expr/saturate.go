@@ -0,0 +1,145 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// EvalSaturatingInt evaluates the expression in int64 arithmetic where results that would
+// overflow are clamped to math.MaxInt64 or math.MinInt64 instead of wrapping around.
+// All numbers must be integers; numbers outside the int64 range are clamped as well.
+// A division truncates towards zero, as integer division in Go does. A power saturates
+// like repeated multiplication; a negative exponent truncates towards zero like a
+// division, so 2 ^ -1 is 0.
+func EvalSaturatingInt(e Expr) (int64, error) {
+	switch e := e.(type) {
+	case num, literal:
+		i, err := evalBigInt(e, false)
+		if err != nil {
+			return 0, err
+		}
+		if !i.IsInt64() {
+			return clamp(i.Sign() < 0), nil
+		}
+		return i.Int64(), nil
+
+	case unary:
+		x, err := EvalSaturatingInt(e.x)
+		if err != nil {
+			return 0, err
+		}
+		switch e.op {
+		case '+':
+			return x, nil
+		case '-':
+			return saturatingSub(0, x), nil
+		}
+		return 0, fmt.Errorf("unsupported unary operator: %q", e.op)
+
+	case binary:
+		x, err := EvalSaturatingInt(e.x)
+		if err != nil {
+			return 0, err
+		}
+		y, err := EvalSaturatingInt(e.y)
+		if err != nil {
+			return 0, err
+		}
+		switch e.op {
+		case '+':
+			return saturatingAdd(x, y), nil
+		case '-':
+			return saturatingSub(x, y), nil
+		case '*':
+			return saturatingMul(x, y), nil
+		case '/':
+			if y == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			if x == math.MinInt64 && y == -1 {
+				return math.MaxInt64, nil
+			}
+			return x / y, nil
+		case '%':
+			if y == 0 {
+				return 0, fmt.Errorf("modulo by zero")
+			}
+			return x % y, nil // never overflows, math.MinInt64 % -1 is 0
+		case '^':
+			if y < 0 {
+				return negativePow(x, y)
+			}
+			return saturatingPow(x, y), nil
+		}
+		return 0, fmt.Errorf("unsupported binary operator: %q", e.op)
+	}
+	return 0, fmt.Errorf("cannot evaluate %v as an integer", e)
+}
+
+// clamp returns the int64 bound in the direction of the overflow.
+func clamp(negative bool) int64 {
+	if negative {
+		return math.MinInt64
+	}
+	return math.MaxInt64
+}
+
+func saturatingAdd(x, y int64) int64 {
+	s := x + y
+	// the sum overflowed if both operands have the same sign and the sum has the other one
+	if (x >= 0) == (y >= 0) && (s >= 0) != (x >= 0) {
+		return clamp(x < 0)
+	}
+	return s
+}
+
+func saturatingSub(x, y int64) int64 {
+	d := x - y
+	// the difference overflowed if the operands have different signs and it has the sign of y
+	if (x >= 0) != (y >= 0) && (d >= 0) != (x >= 0) {
+		return clamp(x < 0)
+	}
+	return d
+}
+
+func saturatingMul(x, y int64) int64 {
+	negative := (x < 0) != (y < 0)
+	hi, lo := bits.Mul64(absUint(x), absUint(y))
+	limit := uint64(math.MaxInt64)
+	if negative {
+		limit++ // the negative range is one larger
+	}
+	if hi != 0 || lo > limit {
+		return clamp(negative)
+	}
+	if negative {
+		return int64(-lo) // also right for lo == 1<<63, which wraps to math.MinInt64
+	}
+	return int64(lo)
+}
+
+// saturatingPow returns x ^ y for y >= 0 by repeated squaring. Only a base of at least 2
+// in magnitude can overflow, and then every further factor keeps a clamped square or
+// partial product clamped, with the sign of the exact result.
+func saturatingPow(x, y int64) int64 {
+	r := int64(1)
+	for {
+		if y&1 == 1 {
+			r = saturatingMul(r, x)
+		}
+		y >>= 1
+		if y == 0 {
+			return r
+		}
+		x = saturatingMul(x, x)
+	}
+}
+
+// absUint returns |x| as an unsigned number, in which |math.MinInt64| still fits.
+func absUint(x int64) uint64 {
+	if x < 0 {
+		return -uint64(x)
+	}
+	return uint64(x)
+}
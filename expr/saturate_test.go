@@ -0,0 +1,54 @@
+package expr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvalSaturatingInt(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"2 + 3 * 4", 14},
+		{"4294967296 * 4294967296", math.MaxInt64},
+		{"-4294967296 * 4294967296", math.MinInt64},
+		{"-4294967296 * -4294967296", math.MaxInt64},
+		{"3037000499 * 3037000499", 9223372030926249001}, // largest square that fits
+		{"-3037000500 * 3037000500", math.MinInt64},
+		{"4611686018427387904 + 4611686018427387904", math.MaxInt64},
+		{"-4611686018427387904 - 4611686018427387904", math.MinInt64},
+		{"-4611686018427387904 - 4611686018427387904 - 1", math.MinInt64},
+		{"-4611686018427387904 * 2", math.MinInt64},
+		{"-(-4611686018427387904 * 2)", math.MaxInt64},
+		{"-4611686018427387904 * 2 / -1", math.MaxInt64},
+		{"1e30", math.MaxInt64},
+		{"-1e30 + 5", -math.MaxInt64 + 5}, // the sign applies to the clamped 1e30
+		{"7 / -2", -3},
+		{"2 ^ 62", 4611686018427387904},
+		{"2 ^ 63", math.MaxInt64},
+		{"(-2) ^ 63", math.MinInt64},
+		{"(-2) ^ 64", math.MaxInt64},
+		{"(-3) ^ 41", math.MinInt64},
+		{"10 ^ 1000000000", math.MaxInt64},
+		{"(-1) ^ 1000000001", -1},
+		{"2 ^ -1", 0},
+	}
+	for _, test := range tests {
+		got, err := EvalSaturatingInt(mustParse(t, test.input))
+		if err != nil {
+			t.Fatalf("EvalSaturatingInt(%q) failed: %v", test.input, err)
+		}
+		if got != test.want {
+			t.Errorf("EvalSaturatingInt(%q) = %d, want %d", test.input, got, test.want)
+		}
+	}
+}
+
+func TestEvalSaturatingIntErrors(t *testing.T) {
+	for _, input := range []string{"1 / 0", "0.5 * 2", "0 ^ -1"} {
+		if _, err := EvalSaturatingInt(mustParse(t, input)); err == nil {
+			t.Errorf("EvalSaturatingInt(%q) succeeded, want error", input)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package expr
+
+// EvalScaled evaluates the expression with every number multiplied by inScale, and returns
+// the result multiplied by outScale, e.g. to apply a unit conversion uniformly. Only the
+// numbers are scaled, so the formula has to be linear in them for the conversion to make
+// sense: with inScale 0.001, 2 + 3 is 0.005, but 2 * 3 is 0.000006. Constants like pi
+// are not scaled: with inScale 2, 2 * pi is 4π.
+func EvalScaled(e Expr, inScale, outScale float64) (float64, error) {
+	scaled := ReplaceFunc(e, func(e Expr) (Expr, bool) {
+		if n, ok := numValue(e); ok {
+			return num(float64(n) * inScale), true
+		}
+		return nil, false
+	})
+	res, err := scaled.Eval()
+	if err != nil {
+		return 0, err
+	}
+	return res * outScale, nil
+}
@@ -0,0 +1,32 @@
+package expr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvalScaled(t *testing.T) {
+	tests := []struct {
+		input             string
+		inScale, outScale float64
+		want              float64
+	}{
+		{"2 + 3", 10, 1, 50},
+		{"2 + 3", 1, 0.5, 2.5},
+		{"2 + 3", 4, 0.25, 5},
+		{"-(2 + 3) * 2", 10, 1, -1000},
+		{"2 * pi", 2, 1, 4 * math.Pi}, // constants are not scaled
+	}
+	for _, test := range tests {
+		got, err := EvalScaled(mustParse(t, test.input), test.inScale, test.outScale)
+		if err != nil {
+			t.Fatalf("EvalScaled(%q) failed: %v", test.input, err)
+		}
+		if got != test.want {
+			t.Errorf("EvalScaled(%q, %v, %v) = %v, want %v", test.input, test.inScale, test.outScale, got, test.want)
+		}
+	}
+	if _, err := EvalScaled(mustParse(t, "1 / 0"), 2, 2); err == nil {
+		t.Error("EvalScaled of a division by zero succeeded, want error")
+	}
+}
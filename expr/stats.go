@@ -0,0 +1,65 @@
+package expr
+
+import (
+	"io"
+	"slices"
+)
+
+// Stats describes the structure of a parsed expression.
+type Stats struct {
+	Nodes         int          // number of nodes in the tree, as reported by Len()
+	Depth         int          // length of the longest path from the root to a number
+	MaxParenDepth int          // deepest nesting of parentheses in the source
+	Ops           map[rune]int // occurrences of each operator, unary and binary ones alike
+}
+
+// Operators returns the operators occurring in the expression in ascending order,
+// for iterating over Ops in a reproducible order.
+func (s Stats) Operators() []rune {
+	ops := make([]rune, 0, len(s.Ops))
+	for op := range s.Ops {
+		ops = append(ops, op)
+	}
+	slices.Sort(ops)
+	return ops
+}
+
+// ParseWithStats parses the content from the input reader like Parse and
+// additionally returns statistics about the structure of the expression.
+func ParseWithStats(r io.Reader, opts ...Option) (Expr, Stats, error) {
+	e, lex, err := parse(r, opts)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	s := ExprStats(e)
+	s.MaxParenDepth = lex.maxParens
+	return e, s, nil
+}
+
+// ExprStats computes the statistics of an already parsed expression.
+// Parentheses leave no trace in the tree, so MaxParenDepth is not set.
+func ExprStats(e Expr) Stats {
+	s := Stats{Ops: make(map[rune]int)}
+	s.Depth = s.collect(e)
+	return s
+}
+
+// collect counts the nodes and operators below e and returns the depth of e.
+func (s *Stats) collect(e Expr) int {
+	s.Nodes++
+	switch e := e.(type) {
+	case unary:
+		s.Ops[e.op]++
+		return s.collect(e.x) + 1
+	case binary:
+		s.Ops[e.op]++
+		return max(s.collect(e.x), s.collect(e.y)) + 1
+	case call:
+		depth := 0
+		for _, a := range e.args {
+			depth = max(depth, s.collect(a))
+		}
+		return depth + 1
+	}
+	return 1
+}
@@ -0,0 +1,169 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseWithStats(t *testing.T) {
+	e, stats, err := ParseWithStats(strings.NewReader("-(1 + 2) * 3 + 4 * 5"))
+	if err != nil {
+		t.Fatalf("ParseWithStats failed: %v", err)
+	}
+	if stats.Nodes != e.Len() {
+		t.Errorf("Nodes = %d, want Len() = %d", stats.Nodes, e.Len())
+	}
+	if stats.Depth != 5 {
+		t.Errorf("Depth = %d, want 5", stats.Depth)
+	}
+	want := map[rune]int{'+': 2, '*': 2, '-': 1}
+	for op, n := range want {
+		if stats.Ops[op] != n {
+			t.Errorf("Ops[%c] = %d, want %d", op, stats.Ops[op], n)
+		}
+	}
+	if len(stats.Ops) != len(want) {
+		t.Errorf("Ops = %v, want %v", stats.Ops, want)
+	}
+}
+
+func TestMaxParenDepth(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"1 + 2", 0},
+		{"(1 + 2) * (3 + 4)", 1},
+		{"((1) + (2 * (3 - (4))))", 4},
+	}
+	for _, test := range tests {
+		_, stats, err := ParseWithStats(strings.NewReader(test.input))
+		if err != nil {
+			t.Fatalf("could not parse %q: %v", test.input, err)
+		}
+		if stats.MaxParenDepth != test.want {
+			t.Errorf("MaxParenDepth of %q = %d, want %d", test.input, stats.MaxParenDepth, test.want)
+		}
+	}
+}
+
+func TestWithMaxParenDepth(t *testing.T) {
+	input := "((1) + (2 * (3 - (4))))"
+	for _, parse := range []func(string, ...Option) (Expr, error){
+		func(s string, opts ...Option) (Expr, error) { return Parse(strings.NewReader(s), opts...) },
+		func(s string, opts ...Option) (Expr, error) { return EvalParse(strings.NewReader(s), opts...) },
+	} {
+		if _, err := parse(input, WithMaxParenDepth(4)); err != nil {
+			t.Errorf("parsing at the limit failed: %v", err)
+		}
+		if _, err := parse(input, WithMaxParenDepth(3)); err == nil {
+			t.Error("parsing beyond the limit succeeded, want error")
+		}
+		if _, err := parse(strings.Repeat("(", 10000)+"1"+strings.Repeat(")", 10000), WithMaxParenDepth(100)); err == nil {
+			t.Error("parsing deeply nested input succeeded, want error")
+		}
+	}
+}
+
+func TestWithEmptyAsZero(t *testing.T) {
+	for _, parse := range []func(string, ...Option) (Expr, error){
+		func(s string, opts ...Option) (Expr, error) { return Parse(strings.NewReader(s), opts...) },
+		func(s string, opts ...Option) (Expr, error) { return EvalParse(strings.NewReader(s), opts...) },
+	} {
+		for _, input := range []string{"", "  \n\t "} {
+			if _, err := parse(input); err == nil {
+				t.Errorf("parsing %q succeeded, want error", input)
+			}
+			e, err := parse(input, WithEmptyAsZero())
+			if err != nil {
+				t.Fatalf("lenient parsing of %q failed: %v", input, err)
+			}
+			if got, _ := e.Eval(); got != 0 {
+				t.Errorf("lenient parsing of %q evaluates to %v, want 0", input, got)
+			}
+		}
+		// only the empty input is lenient, an incomplete expression still fails
+		if _, err := parse("1 +", WithEmptyAsZero()); err == nil {
+			t.Error("lenient parsing of \"1 +\" succeeded, want error")
+		}
+	}
+}
+
+func TestStatsOperators(t *testing.T) {
+	want := []rune{'*', '+', '-', '/'}
+	for i := 0; i < 10; i++ { // map iteration order differs between runs
+		got := ExprStats(mustParse(t, "-1 / 2 + 3 * 4 - 5 / 6")).Operators()
+		if string(got) != string(want) {
+			t.Fatalf("Operators = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestWithStrictEval(t *testing.T) {
+	e, err := EvalParse(strings.NewReader("2 * (1 / 0) + 3"))
+	if err != nil {
+		t.Fatalf("lenient EvalParse failed: %v", err)
+	}
+	if got, _ := e.Eval(); got != 3 {
+		t.Errorf("lenient EvalParse = %v, want 3 with the division as 0", got)
+	}
+	if _, err := EvalParse(strings.NewReader("2 * (1 / 0) + 3"), WithStrictEval()); err == nil {
+		t.Error("strict EvalParse of a division by zero succeeded, want error")
+	}
+}
+
+func TestWithFiniteEval(t *testing.T) {
+	for _, input := range []string{"1 / (1e308 * 10)", "1 / exp(1000)", "-(2 ^ 2000) + 1"} {
+		e, err := EvalParse(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("EvalParse(%q) failed: %v", input, err)
+		}
+		want, _ := mustParse(t, input).Eval()
+		if got, _ := e.Eval(); got != want {
+			t.Errorf("EvalParse(%q) = %v, want %v", input, got, want)
+		}
+		if _, err := EvalParse(strings.NewReader(input), WithFiniteEval()); err == nil || !strings.Contains(err.Error(), "not finite") {
+			t.Errorf("EvalParse(%q) with WithFiniteEval returned %v, want error", input, err)
+		}
+	}
+	if _, err := EvalParse(strings.NewReader("1e308 + 1e308 / 2"), WithFiniteEval()); err != nil {
+		t.Errorf("EvalParse of a finite sum with WithFiniteEval failed: %v", err)
+	}
+}
+
+func TestWithFoldUnaryLiterals(t *testing.T) {
+	tests := []struct {
+		input string
+		len   int // Len of the folded tree
+	}{
+		{"-5", 1},
+		{"+5", 1},
+		{"-5 * 3", 3},
+		{"2 - -5.5", 3},
+		{"--5", 2},  // only the inner sign is directly in front of the number
+		{"-(5)", 2}, // a sign in front of parentheses stays
+		{"-(1 + 2)", 4},
+		{"-5 ^ 2", 4}, // the sign applies to the power, -(5 ^ 2)
+	}
+	for _, test := range tests {
+		plain := mustParse(t, test.input)
+		for _, opts := range [][]Option{{WithFoldUnaryLiterals()}, {WithFoldUnaryLiterals(), WithSourceLiterals()}} {
+			e, err := Parse(strings.NewReader(test.input), opts...)
+			if err != nil {
+				t.Fatalf("could not parse %q: %v", test.input, err)
+			}
+			if e.Len() != test.len {
+				t.Errorf("Len of folded %q = %d, want %d", test.input, e.Len(), test.len)
+			}
+			got, _ := e.Eval()
+			want, _ := plain.Eval()
+			if got != want {
+				t.Errorf("folded %q evaluates to %v, want %v", test.input, got, want)
+			}
+			// the canonical form parses back into the folded tree, even without folding
+			if back, err := Parse(strings.NewReader(Canonical(e)), opts[1:]...); err != nil || !Same(back, e) {
+				t.Errorf("folded %q does not round-trip: Canonical = %s, parsed back as %v, %v", test.input, Canonical(e), back, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package expr
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/scanner"
+)
+
+// A NodeHandler receives the parts of an expression from ParseStream as they are parsed.
+// Numbers arrive in source order and every operator arrives right after its operands
+// (postfix order), so a handler can evaluate the input with a stack, without a tree.
+// Parentheses are reported when they are consumed. An error returned by a handler
+// stops the parsing and is returned by ParseStream. Constants like pi are reported as
+// numbers; function calls and variables are not supported.
+type NodeHandler interface {
+	OnNumber(x float64) error
+	OnOperator(op rune, operands int) error // operands is 1 for a sign and 2 for a binary operator
+	OnOpenParen() error
+	OnCloseParen() error
+}
+
+// ParseStream parses the content from the input reader like Parse, but instead of
+// building a tree it reports each part of the expression to the handler.
+// Apart from the recursion of the parser, it runs in constant memory.
+func ParseStream(r io.Reader, handler NodeHandler, opts ...Option) error {
+	lex := newLexer(r, opts)
+	lex.next() // initial lookahead
+	if err := streamBinary(lex, handler, 1); err != nil {
+		return fmt.Errorf("could not parse %s: %s", lex, err)
+	}
+	if lex.token != scanner.EOF {
+		return fmt.Errorf("unexpected %s", lex)
+	}
+	return nil
+}
+
+// streamBinary follows parseBinary, reporting each operator after its right operand.
+func streamBinary(lex *lexer, h NodeHandler, prio0 int) error {
+	if err := streamUnary(lex, h); err != nil {
+		return err
+	}
+	for prio := priority(lex.token); prio >= prio0; prio-- {
+		for priority(lex.token) == prio {
+			op := lex.token
+			lex.next() // consume operator
+			if err := streamBinary(lex, h, prio+1); err != nil {
+				return err
+			}
+			if err := h.OnOperator(op, 2); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func streamUnary(lex *lexer, h NodeHandler) error {
+	if isPrefix(lex.token) {
+		op := lex.token
+		lex.next() // consume '+', '-' or prefix operator
+		if err := streamUnary(lex, h); err != nil {
+			return err
+		}
+		return h.OnOperator(op, 1)
+	}
+	return streamPower(lex, h)
+}
+
+// streamPower follows parsePower, reporting '^' after the exponent.
+func streamPower(lex *lexer, h NodeHandler) error {
+	if err := streamPrimary(lex, h); err != nil || lex.token != '^' {
+		return err
+	}
+	lex.next() // consume '^'
+	if err := streamUnary(lex, h); err != nil {
+		return err
+	}
+	return h.OnOperator('^', 2)
+}
+
+func streamPrimary(lex *lexer, h NodeHandler) error {
+	switch lex.token {
+	case scanner.Int, scanner.Float:
+		text, err := lex.numberText()
+		if err != nil {
+			return err
+		}
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("could not parse the float number %s: %s", lex, err)
+		}
+		lex.next() // consume number
+		return h.OnNumber(f)
+
+	case scanner.Ident:
+		c, ok := constants[lex.text()]
+		if !ok {
+			break
+		}
+		lex.next() // consume name
+		return h.OnNumber(c)
+
+	case '(':
+		if err := lex.openParen(); err != nil {
+			return err
+		}
+		lex.next() // consume '('
+		if err := h.OnOpenParen(); err != nil {
+			return err
+		}
+		if err := streamBinary(lex, h, 1); err != nil {
+			return err
+		}
+		if lex.token != ')' {
+			return fmt.Errorf("got %s, want ')'", lex)
+		}
+		lex.closeParen()
+		lex.next() // consume ')'
+		return h.OnCloseParen()
+	}
+	return fmt.Errorf("unexpected %s", lex)
+}
@@ -0,0 +1,95 @@
+package expr
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// sumHandler adds up all numbers of a sum, which it rejects if it has other operators.
+type sumHandler struct {
+	sum float64
+}
+
+func (h *sumHandler) OnNumber(x float64) error { h.sum += x; return nil }
+func (h *sumHandler) OnOperator(op rune, operands int) error {
+	if op != '+' || operands != 2 {
+		return fmt.Errorf("not a sum: %c", op)
+	}
+	return nil
+}
+func (h *sumHandler) OnOpenParen() error  { return nil }
+func (h *sumHandler) OnCloseParen() error { return nil }
+
+// stackHandler evaluates the expression with a stack, as a calculator in RPN mode.
+type stackHandler struct {
+	stack []float64
+}
+
+func (h *stackHandler) OnNumber(x float64) error { h.stack = append(h.stack, x); return nil }
+func (h *stackHandler) OnOperator(op rune, operands int) error {
+	n := len(h.stack)
+	var e Expr = unary{op, num(h.stack[n-1])}
+	if operands == 2 {
+		e = binary{op, num(h.stack[n-2]), num(h.stack[n-1])}
+	}
+	res, err := e.Eval()
+	h.stack = append(h.stack[:n-operands], res)
+	return err
+}
+func (h *stackHandler) OnOpenParen() error  { return nil }
+func (h *stackHandler) OnCloseParen() error { return nil }
+
+func TestParseStreamRunningSum(t *testing.T) {
+	const terms = 100000
+	input := strings.Repeat("1.5 + ", terms-1) + "1.5"
+
+	var h sumHandler
+	if err := ParseStream(strings.NewReader(input), &h); err != nil {
+		t.Fatalf("ParseStream failed: %v", err)
+	}
+	if want := 1.5 * terms; h.sum != want {
+		t.Errorf("running sum = %g, want %g", h.sum, want)
+	}
+
+	if err := ParseStream(strings.NewReader("1 + 2 * 3"), &h); err == nil {
+		t.Error("handler error did not stop ParseStream")
+	}
+}
+
+func TestParseStreamEvaluatesLikeEval(t *testing.T) {
+	content, err := os.ReadFile("../testdata/10k.txt")
+	if err != nil {
+		t.Fatalf("could not read file: %v", err)
+	}
+	var h stackHandler
+	if err := ParseStream(strings.NewReader(string(content)), &h); err != nil {
+		t.Fatalf("ParseStream failed: %v", err)
+	}
+	want, _ := mustParse(t, string(content)).Eval()
+	if len(h.stack) != 1 || h.stack[0] != want {
+		t.Errorf("stack = %v, want [%g]", h.stack, want)
+	}
+}
+
+func TestParseStreamConstants(t *testing.T) {
+	var h stackHandler
+	if err := ParseStream(strings.NewReader("2 * pi - tau"), &h); err != nil {
+		t.Fatalf("ParseStream failed: %v", err)
+	}
+	if len(h.stack) != 1 || h.stack[0] != 0 {
+		t.Errorf("stack = %v, want [0]", h.stack)
+	}
+}
+
+func TestParseStreamSyntaxError(t *testing.T) {
+	if err := ParseStream(strings.NewReader("(1 + 2"), &stackHandler{}); err == nil {
+		t.Error("ParseStream succeeded on unbalanced parentheses")
+	}
+	for _, input := range []string{"2 * x", "sqrt(4)"} {
+		if err := ParseStream(strings.NewReader(input), &stackHandler{}); err == nil {
+			t.Errorf("ParseStream(%q) succeeded, want error", input)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Summary renders the expression like Canonical, but only down to maxDepth levels
+// below the root. Deeper subtrees are replaced by a marker with their number of nodes,
+// like "[… 57 nodes]", so that huge expressions give a readable overview.
+// Numbers, constants and variables are always shown, as a marker would not be shorter.
+func Summary(e Expr, maxDepth int) string {
+	var b strings.Builder
+	writeSummary(&b, e, maxDepth)
+	return b.String()
+}
+
+func writeSummary(b *strings.Builder, e Expr, depth int) {
+	_, isVar := e.(variable)
+	_, isConst := e.(constant)
+	if _, ok := numValue(e); !ok && !isVar && !isConst && depth <= 0 {
+		fmt.Fprintf(b, "[… %d nodes]", e.Len())
+		return
+	}
+	write := func(b *strings.Builder, x Expr) { writeSummary(b, x, depth-1) }
+	switch e := e.(type) {
+	case unary:
+		b.WriteRune(e.op)
+		writeOperand(b, e.x, needParens(e, e.x, false) && depth > 1, write)
+	case binary:
+		writeOperand(b, e.x, needParens(e, e.x, false) && depth > 1, write)
+		b.WriteString(" ")
+		b.WriteRune(e.op)
+		b.WriteString(" ")
+		writeOperand(b, e.y, needParens(e, e.y, true) && depth > 1, write)
+	case call:
+		writeCall(b, e, write)
+	default:
+		writeCanonical(b, e)
+	}
+}
@@ -0,0 +1,59 @@
+package expr
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSummary(t *testing.T) {
+	e := mustParse(t, "(1 + 2 * 3) * -(4 - 5 / 6) + 7")
+	tests := []struct {
+		depth int
+		want  string
+	}{
+		{0, "[… 14 nodes]"},
+		{1, "[… 12 nodes] + 7"},
+		{2, "[… 5 nodes] * [… 6 nodes] + 7"},
+		{3, "(1 + [… 3 nodes]) * -[… 5 nodes] + 7"},
+		{10, "(1 + 2 * 3) * -(4 - 5 / 6) + 7"},
+	}
+	for _, test := range tests {
+		if got := Summary(e, test.depth); got != test.want {
+			t.Errorf("Summary(%d) = %q, want %q", test.depth, got, test.want)
+		}
+	}
+}
+
+func TestSummaryOfCalls(t *testing.T) {
+	e := mustParse(t, "max(1 + 2 * 3, x) + 7")
+	tests := []struct {
+		depth int
+		want  string
+	}{
+		{1, "[… 7 nodes] + 7"},
+		{2, "max([… 5 nodes], x) + 7"},
+		{3, "max(1 + [… 3 nodes], x) + 7"},
+		{10, "max(1 + 2 * 3, x) + 7"},
+	}
+	for _, test := range tests {
+		if got := Summary(e, test.depth); got != test.want {
+			t.Errorf("Summary(%d) = %q, want %q", test.depth, got, test.want)
+		}
+	}
+}
+
+func TestSummaryOfLargeFile(t *testing.T) {
+	content, err := os.ReadFile("../testdata/100k.txt")
+	if err != nil {
+		t.Fatalf("could not read file: %v", err)
+	}
+	e := mustParse(t, string(content))
+	s := Summary(e, 5)
+	if len(s) > 1000 {
+		t.Errorf("summary is %d bytes long, want a short overview", len(s))
+	}
+	if !strings.Contains(s, "nodes]") {
+		t.Errorf("summary %q has no truncation markers", s)
+	}
+}
@@ -0,0 +1,93 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// EvalStruct computes the fields of the struct pointed to by v that have a calc tag holding
+// a formula, like `calc:"Width * Height"`. The variables of the formulas are the other
+// numeric fields of the struct, by name, and the fields computed before them, so a
+// formula can build on the fields tagged above it. Computed fields must be floats, or
+// integers if the result is integral.
+func EvalStruct(v any) error {
+	p := reflect.ValueOf(v)
+	if p.Kind() != reflect.Pointer || p.IsNil() || p.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("EvalStruct needs a pointer to a struct, got %T", v)
+	}
+	s := p.Elem()
+	env := make(map[string]float64)
+	for i := 0; i < s.NumField(); i++ {
+		f := s.Type().Field(i)
+		if _, ok := f.Tag.Lookup("calc"); ok || !f.IsExported() {
+			continue
+		}
+		if x, ok := floatOf(s.Field(i)); ok {
+			env[f.Name] = x
+		}
+	}
+	for i := 0; i < s.NumField(); i++ {
+		f := s.Type().Field(i)
+		formula, ok := f.Tag.Lookup("calc")
+		if !ok {
+			continue
+		}
+		if !f.IsExported() {
+			return fmt.Errorf("field %s: cannot set an unexported field", f.Name)
+		}
+		e, err := Parse(strings.NewReader(formula))
+		if err != nil {
+			return fmt.Errorf("field %s: %s", f.Name, err)
+		}
+		x, err := EvalEnv(e, env)
+		if err != nil {
+			return fmt.Errorf("field %s: %s", f.Name, err)
+		}
+		if err := setFloat(s.Field(i), x); err != nil {
+			return fmt.Errorf("field %s: %s", f.Name, err)
+		}
+		env[f.Name] = x
+	}
+	return nil
+}
+
+// floatOf returns the value of a numeric field.
+func floatOf(v reflect.Value) (float64, bool) {
+	switch {
+	case v.CanFloat():
+		return v.Float(), true
+	case v.CanInt():
+		return float64(v.Int()), true
+	case v.CanUint():
+		return float64(v.Uint()), true
+	}
+	return 0, false
+}
+
+// setFloat sets a numeric field to x.
+func setFloat(v reflect.Value, x float64) error {
+	switch {
+	case v.CanFloat():
+		v.SetFloat(x)
+		return nil
+	case v.CanInt(), v.CanUint():
+		if x != math.Trunc(x) || math.IsInf(x, 0) {
+			return fmt.Errorf("result %v is not an integer", x)
+		}
+		if v.CanInt() {
+			if x < math.MinInt64 || x >= math.MaxInt64 || v.OverflowInt(int64(x)) {
+				return fmt.Errorf("result %v overflows %s", x, v.Type())
+			}
+			v.SetInt(int64(x))
+			return nil
+		}
+		if x < 0 || x >= math.MaxUint64 || v.OverflowUint(uint64(x)) {
+			return fmt.Errorf("result %v overflows %s", x, v.Type())
+		}
+		v.SetUint(uint64(x))
+		return nil
+	}
+	return fmt.Errorf("cannot set a %s", v.Type())
+}
@@ -0,0 +1,54 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvalStruct(t *testing.T) {
+	var room struct {
+		Width, Length float64
+		Floors        int
+		Area          float64 `calc:"Width * Length"`
+		Total         float64 `calc:"Area * Floors"`
+		Diagonal      float32 `calc:"hypot(Width, Length)"`
+		Tiles         int     `calc:"ceil(Total / 0.25)"`
+	}
+	room.Width, room.Length, room.Floors = 3, 4, 2
+	if err := EvalStruct(&room); err != nil {
+		t.Fatalf("EvalStruct failed: %v", err)
+	}
+	if room.Area != 12 || room.Total != 24 || room.Diagonal != 5 || room.Tiles != 96 {
+		t.Errorf("EvalStruct computed %+v, want area 12, total 24, diagonal 5 and 96 tiles", room)
+	}
+
+	tests := []struct {
+		v    any
+		want string
+	}{
+		{room, "pointer to a struct"},
+		{&struct {
+			X int `calc:"1 / 2"`
+		}{}, "not an integer"},
+		{&struct {
+			X float64 `calc:"Y + 1"`
+		}{}, "undefined variable Y"},
+		{&struct {
+			X float64 `calc:"1 +"`
+		}{}, "field X"},
+		{&struct {
+			X uint8 `calc:"2 ^ 8"`
+		}{}, "overflows"},
+		{&struct {
+			X string `calc:"1"`
+		}{}, "cannot set"},
+		{&struct {
+			X int `calc:"1 / 0"`
+		}{}, "division by zero"},
+	}
+	for _, test := range tests {
+		if err := EvalStruct(test.v); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("EvalStruct(%T) returned %v, want an error containing %q", test.v, err, test.want)
+		}
+	}
+}
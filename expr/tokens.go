@@ -0,0 +1,85 @@
+package expr
+
+import (
+	"fmt"
+	"io"
+	"text/scanner"
+)
+
+// A TokenKind classifies the tokens of an arithmetic expression.
+type TokenKind int
+
+const (
+	NumberToken     TokenKind = iota // integer or float literal
+	OperatorToken                    // one of '+', '-', '*', '/', '%', '^' or the '@' of an application
+	LeftParenToken                   // '('
+	RightParenToken                  // ')'
+	IdentToken                       // identifier: a constant, a function or a variable
+	OtherToken                       // any other rune
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case NumberToken:
+		return "number"
+	case OperatorToken:
+		return "operator"
+	case LeftParenToken:
+		return "left parenthesis"
+	case RightParenToken:
+		return "right parenthesis"
+	case IdentToken:
+		return "identifier"
+	}
+	return "other"
+}
+
+// A Token is a lexical unit of an expression, with its position in the source.
+type Token struct {
+	Kind TokenKind
+	Text string
+	Pos  scanner.Position
+}
+
+func (t Token) String() string {
+	return fmt.Sprintf("%s %q at %s", t.Kind, t.Text, t.Pos)
+}
+
+// Tokens reads the whole input and returns its tokens, as seen by the lexer of Parse.
+// It is meant for small inputs, like in tests or for syntax highlighting.
+func Tokens(r io.Reader) ([]Token, error) {
+	lex := newLexer(r, nil)
+
+	// report scanner errors instead of printing them on stderr
+	var scanErr error
+	lex.scan.Error = func(s *scanner.Scanner, msg string) {
+		if scanErr == nil {
+			scanErr = fmt.Errorf("%s: %s", s.Position, msg)
+		}
+	}
+
+	var tokens []Token
+	for lex.next(); lex.token != scanner.EOF; lex.next() {
+		tokens = append(tokens, Token{tokenKind(lex.token), lex.text(), lex.scan.Position})
+	}
+	if scanErr != nil {
+		return nil, scanErr
+	}
+	return tokens, nil
+}
+
+func tokenKind(token rune) TokenKind {
+	switch token {
+	case scanner.Int, scanner.Float:
+		return NumberToken
+	case scanner.Ident:
+		return IdentToken
+	case '+', '-', '*', '/', '%', '^', '@':
+		return OperatorToken
+	case '(':
+		return LeftParenToken
+	case ')':
+		return RightParenToken
+	}
+	return OtherToken
+}
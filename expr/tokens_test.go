@@ -0,0 +1,42 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	tokens, err := Tokens(strings.NewReader("-(3+4)*2.5"))
+	if err != nil {
+		t.Fatalf("Tokens failed: %v", err)
+	}
+	want := []struct {
+		kind   TokenKind
+		text   string
+		offset int
+	}{
+		{OperatorToken, "-", 0},
+		{LeftParenToken, "(", 1},
+		{NumberToken, "3", 2},
+		{OperatorToken, "+", 3},
+		{NumberToken, "4", 4},
+		{RightParenToken, ")", 5},
+		{OperatorToken, "*", 6},
+		{NumberToken, "2.5", 7},
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("got %d tokens %v, want %d", len(tokens), tokens, len(want))
+	}
+	for i, w := range want {
+		got := tokens[i]
+		if got.Kind != w.kind || got.Text != w.text || got.Pos.Offset != w.offset {
+			t.Errorf("token %d = %v, want %s %q at offset %d", i, got, w.kind, w.text, w.offset)
+		}
+	}
+}
+
+func TestTokensScanError(t *testing.T) {
+	if _, err := Tokens(strings.NewReader("1 + 0x")); err == nil {
+		t.Error("Tokens succeeded on a malformed number, want error")
+	}
+}
@@ -0,0 +1,125 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+)
+
+// EvalTracked evaluates the expression like Eval and also returns a bound on the rounding
+// error accumulated by the float64 operations, in units in the last place (ULPs) of the
+// result. A small bound means that all but the last few digits of the result can be
+// trusted; a large one, as in 1e16 + 3 - 1e16, means that cancellation ate the digits.
+// The numbers themselves are taken as exact, the bound only covers the arithmetic and
+// the rounding of constants like pi.
+// Registered prefix operators and most functions have an unknown error, which makes the
+// bound infinite; only abs, min and max, which never round, keep it finite.
+func EvalTracked(e Expr) (value float64, ulpError float64, err error) {
+	value, abs, err := evalTracked(e)
+	if err != nil {
+		return 0, 0, err
+	}
+	if abs == 0 {
+		return value, 0, nil
+	}
+	return value, abs / ulp(value), nil
+}
+
+// evalTracked returns the value of e and a bound on its absolute rounding error.
+func evalTracked(e Expr) (float64, float64, error) {
+	switch e := e.(type) {
+	case num, literal:
+		v, err := e.Eval()
+		return v, 0, err
+
+	case constant:
+		v, _ := e.Eval()
+		return v, ulp(v) / 2, nil // the float64 closest to an irrational number
+
+	case unary:
+		x, ex, err := evalTracked(e.x)
+		if err != nil {
+			return 0, 0, err
+		}
+		v, err := unary{e.op, num(x)}.Eval()
+		if err != nil {
+			return 0, 0, err
+		}
+		if e.op != '+' && e.op != '-' {
+			return v, math.Inf(1), nil
+		}
+		return v, ex, nil // a sign change is exact
+
+	case binary:
+		x, ex, err := evalTracked(e.x)
+		if err != nil {
+			return 0, 0, err
+		}
+		y, ey, err := evalTracked(e.y)
+		if err != nil {
+			return 0, 0, err
+		}
+		v, err := binary{e.op, num(x), num(y)}.Eval()
+		if err != nil {
+			return 0, 0, fmt.Errorf("evaluation of %v failed: %s", e, err)
+		}
+
+		// the error carried over from the operands, to first order,
+		// plus half an ULP for rounding the result of the operation
+		var carried float64
+		switch e.op {
+		case '+', '-':
+			carried = ex + ey
+		case '*':
+			carried = math.Abs(x)*ey + math.Abs(y)*ex + ex*ey
+		case '/':
+			if ey >= math.Abs(y) {
+				return v, math.Inf(1), nil // the divisor might as well be zero
+			}
+			carried = (ex + math.Abs(v)*ey) / (math.Abs(y) - ey)
+		case '%':
+			// x % y = x - n*y for the integer n = trunc(x/y), which stays put for small errors
+			carried = ex + math.Abs(math.Trunc(x/y))*ey
+		case '^':
+			// d(x^y) = y x^(y-1) dx + x^y ln(x) dy
+			switch {
+			case ey == 0 && x != 0:
+				carried = math.Abs(v*y/x) * ex
+			case x > 0:
+				carried = math.Abs(v) * (math.Abs(y/x)*ex + math.Abs(math.Log(x))*ey)
+			default:
+				return v, math.Inf(1), nil // no bound without the logarithm of the base
+			}
+		default:
+			return v, math.Inf(1), nil
+		}
+		return v, carried + ulp(v)/2, nil
+
+	case call:
+		bound := call{e.name, make([]Expr, len(e.args))}
+		var carried float64 // the largest error of the arguments
+		for i, a := range e.args {
+			x, ex, err := evalTracked(a)
+			if err != nil {
+				return 0, 0, err
+			}
+			bound.args[i] = num(x)
+			carried = max(carried, ex)
+		}
+		v, err := bound.Eval()
+		if err != nil {
+			return 0, 0, fmt.Errorf("evaluation of %v failed: %s", e, err)
+		}
+		switch e.name {
+		case "abs", "min", "max":
+			return v, carried, nil // the result is one of the arguments, up to the sign
+		}
+		return v, math.Inf(1), nil
+	}
+	return 0, 0, fmt.Errorf("cannot evaluate %v with error tracking", e)
+}
+
+// ulp returns the distance from |x| to the next larger float64.
+func ulp(x float64) float64 {
+	x = math.Abs(x)
+	return math.Nextafter(x, math.Inf(1)) - x
+}
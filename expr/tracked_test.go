@@ -0,0 +1,43 @@
+package expr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvalTracked(t *testing.T) {
+	registerSqrt(t)
+	// exact operations only lose half an ULP each, at most
+	v, ulps, err := EvalTracked(mustParse(t, "(1 + 2) * 4 / 2"))
+	if err != nil {
+		t.Fatalf("EvalTracked failed: %v", err)
+	}
+	if v != 6 || ulps > 2 {
+		t.Errorf("EvalTracked((1 + 2) * 4 / 2) = %v, %v ULPs, want 6 with at most 2 ULPs", v, ulps)
+	}
+
+	// 1e16 + 3 rounds to 1e16 + 4, and the cancellation leaves nothing but that error
+	v, ulps, err = EvalTracked(mustParse(t, "1e16 + 3 - 1e16"))
+	if err != nil {
+		t.Fatalf("EvalTracked failed: %v", err)
+	}
+	if v != 4 || ulps < 1e14 {
+		t.Errorf("EvalTracked(1e16 + 3 - 1e16) = %v, %v ULPs, want 4 with a huge error", v, ulps)
+	}
+
+	if _, ulps, _ := EvalTracked(mustParse(t, "√2")); !math.IsInf(ulps, 1) {
+		t.Errorf("EvalTracked(√2) has an error of %v ULPs, want infinity", ulps)
+	}
+	if v, ulps, err := EvalTracked(mustParse(t, "sqrt(2) + 1")); err != nil || v != math.Sqrt2+1 || !math.IsInf(ulps, 1) {
+		t.Errorf("EvalTracked(sqrt(2) + 1) = %v, %v ULPs, %v, want an error of infinity", v, ulps, err)
+	}
+	if v, ulps, err := EvalTracked(mustParse(t, "max(1, abs(-2)) * 3")); err != nil || v != 6 || ulps > 1 {
+		t.Errorf("EvalTracked(max(1, abs(-2)) * 3) = %v, %v ULPs, %v, want 6 with at most 1 ULP", v, ulps, err)
+	}
+	if _, _, err := EvalTracked(mustParse(t, "sqrt(1 / 0)")); err == nil {
+		t.Error("EvalTracked of a division by zero in an argument succeeded, want error")
+	}
+	if _, _, err := EvalTracked(mustParse(t, "1 / (3 - 3)")); err == nil {
+		t.Error("EvalTracked of a division by zero succeeded, want error")
+	}
+}
@@ -0,0 +1,71 @@
+package expr
+
+// Children returns the operands of e, in order: none for a number, one for a unary
+// operation, two for a binary operation and the arguments of a call. It allows traversing a tree without knowing
+// the concrete node types. The returned slice is new and can be modified by the caller.
+func Children(e Expr) []Expr {
+	switch e := e.(type) {
+	case unary:
+		return []Expr{e.x}
+	case binary:
+		return []Expr{e.x, e.y}
+	case call:
+		return append([]Expr(nil), e.args...)
+	}
+	return nil
+}
+
+// Walk traverses the expression tree in depth-first order, calling fn for each node
+// before its operands. If fn returns false, the operands of that node are skipped.
+func Walk(e Expr, fn func(Expr) bool) {
+	if !fn(e) {
+		return
+	}
+	switch e := e.(type) {
+	case unary:
+		Walk(e.x, fn)
+	case binary:
+		Walk(e.x, fn)
+		Walk(e.y, fn)
+	case call:
+		for _, a := range e.args {
+			Walk(a, fn)
+		}
+	}
+}
+
+// ReplaceFunc returns a copy of the expression in which every node for which fn returns
+// (replacement, true) is replaced. The tree is processed bottom-up: fn sees a node after
+// its operands have been replaced, and the replacement itself is not visited again.
+func ReplaceFunc(e Expr, fn func(Expr) (Expr, bool)) Expr {
+	switch n := e.(type) {
+	case unary:
+		e = unary{n.op, ReplaceFunc(n.x, fn)}
+	case binary:
+		e = binary{n.op, ReplaceFunc(n.x, fn), ReplaceFunc(n.y, fn)}
+	case call:
+		args := make([]Expr, len(n.args))
+		for i, a := range n.args {
+			args[i] = ReplaceFunc(a, fn)
+		}
+		e = call{n.name, args}
+	}
+	if r, ok := fn(e); ok {
+		return r
+	}
+	return e
+}
+
+// Find returns the subtrees of the expression for which pred returns true, in the
+// depth-first order of Walk. A matching subtree is searched for further matches too,
+// so the result may contain nested subtrees.
+func Find(e Expr, pred func(Expr) bool) []Expr {
+	var found []Expr
+	Walk(e, func(e Expr) bool {
+		if pred(e) {
+			found = append(found, e)
+		}
+		return true
+	})
+	return found
+}
@@ -0,0 +1,92 @@
+package expr
+
+import "testing"
+
+func TestWalk(t *testing.T) {
+	var visited []string
+	Walk(mustParse(t, "-(1 + 2) * 3"), func(e Expr) bool {
+		visited = append(visited, KindOf(e).String())
+		return KindOf(e) != AddKind // skip the operands of additions
+	})
+	want := []string{"multiplication", "sign", "addition", "number"}
+	if len(visited) != len(want) {
+		t.Fatalf("visited %q, want %q", visited, want)
+	}
+	for i := range want {
+		if visited[i] != want[i] {
+			t.Errorf("visited %q, want %q", visited, want)
+		}
+	}
+}
+
+func TestReplaceFunc(t *testing.T) {
+	double := func(e Expr) (Expr, bool) {
+		if n, ok := e.(num); ok {
+			return num(2 * n), true
+		}
+		return nil, false
+	}
+	got := ReplaceFunc(mustParse(t, "1 + -2 * (3 - 0.5)"), double)
+	if want := "2 + -4 * (6 - 1)"; Canonical(got) != want {
+		t.Errorf("ReplaceFunc = %q, want %q", Canonical(got), want)
+	}
+
+	// operands are replaced before the node itself is looked at
+	fold := func(e Expr) (Expr, bool) {
+		if b, ok := e.(binary); ok {
+			if _, ok := b.x.(num); ok {
+				if _, ok := b.y.(num); ok {
+					v, err := b.Eval()
+					return num(v), err == nil
+				}
+			}
+		}
+		return nil, false
+	}
+	if got := ReplaceFunc(mustParse(t, "(1 + 2) * (3 + 4) - 1"), fold); got != num(20) {
+		t.Errorf("ReplaceFunc folding constants = %v, want 20", got)
+	}
+}
+
+func TestChildren(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"1.5", nil},
+		{"-(1 + 2)", []string{"1.00 + 2.00"}},
+		{"1 * 2 - 3", []string{"1.00 * 2.00", "3.00"}},
+	}
+	for _, test := range tests {
+		got := Children(mustParse(t, test.input))
+		if len(got) != len(test.want) {
+			t.Fatalf("Children(%q) = %v, want %q", test.input, got, test.want)
+		}
+		for i := range got {
+			if got[i].String() != test.want[i] {
+				t.Errorf("Children(%q)[%d] = %v, want %s", test.input, i, got[i], test.want[i])
+			}
+		}
+	}
+	if got := Children(literal{num(1), "1"}); got != nil {
+		t.Errorf("Children of a literal = %v, want none", got)
+	}
+}
+
+func TestFind(t *testing.T) {
+	isDivision := func(e Expr) bool { return KindOf(e) == DivideKind }
+	got := Find(mustParse(t, "1 / 2 + -(3 * (4 / (5 / 6))) - 7"), isDivision)
+	want := []string{"1 / 2", "4 / (5 / 6)", "5 / 6"}
+	if len(got) != len(want) {
+		t.Fatalf("Find returned %d divisions %v, want %q", len(got), got, want)
+	}
+	for i := range want {
+		if Canonical(got[i]) != want[i] {
+			t.Errorf("division %d = %q, want %q", i, Canonical(got[i]), want[i])
+		}
+	}
+
+	if got := Find(mustParse(t, "1 + 2"), isDivision); len(got) != 0 {
+		t.Errorf("Find without divisions = %v, want none", got)
+	}
+}
@@ -0,0 +1,117 @@
+package expr
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// EvalWrap evaluates the expression in two's complement integer arithmetic of the given
+// bit width (1 to 64), like fixed-width machine integers: results that overflow wrap
+// around, so under 32 bits 2147483648 + 2147483648 is 0 and 2147483647 + 1 is -2147483648.
+// All numbers must be integers; they are wrapped into the range as well.
+// A division truncates towards zero, as integer division in Go does. A power wraps around
+// like repeated multiplication, with an exponent of the same width; a negative exponent
+// truncates towards zero like a division, so 2 ^ -1 is 0.
+func EvalWrap(e Expr, bits int) (int64, error) {
+	if bits < 1 || bits > 64 {
+		return 0, fmt.Errorf("bit width %d not between 1 and 64", bits)
+	}
+	return evalWrap(e, bits)
+}
+
+// wrap keeps the lower bits of v and sign-extends them to 64 bits.
+func wrap(v uint64, bits int) int64 {
+	shift := 64 - bits
+	return int64(v<<shift) >> shift
+}
+
+// wrappingPow returns x ^ y modulo 2^64 by repeated squaring.
+func wrappingPow(x, y uint64) uint64 {
+	r := uint64(1)
+	for ; y > 0; y >>= 1 {
+		if y&1 == 1 {
+			r *= x
+		}
+		x *= x
+	}
+	return r
+}
+
+// negativePow returns x ^ y for a negative y, truncated towards zero like 1 / x ^ -y:
+// it is 0 unless x is 1 or -1, and an error if x is 0.
+func negativePow(x, y int64) (int64, error) {
+	switch {
+	case x == 0:
+		return 0, fmt.Errorf("division by zero")
+	case x == -1 && y%2 != 0:
+		return -1, nil
+	case x == 1 || x == -1:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func evalWrap(e Expr, bits int) (int64, error) {
+	switch e := e.(type) {
+	case num, literal:
+		i, err := evalBigInt(e, false)
+		if err != nil {
+			return 0, err
+		}
+		// reduce modulo 2^64 first, the wrap only looks at the lower bits
+		i.Mod(i, new(big.Int).Lsh(big.NewInt(1), 64))
+		return wrap(i.Uint64(), bits), nil
+
+	case unary:
+		x, err := evalWrap(e.x, bits)
+		if err != nil {
+			return 0, err
+		}
+		switch e.op {
+		case '+':
+			return x, nil
+		case '-':
+			return wrap(-uint64(x), bits), nil
+		}
+		return 0, fmt.Errorf("unsupported unary operator: %q", e.op)
+
+	case binary:
+		x, err := evalWrap(e.x, bits)
+		if err != nil {
+			return 0, err
+		}
+		y, err := evalWrap(e.y, bits)
+		if err != nil {
+			return 0, err
+		}
+		switch e.op {
+		case '+':
+			return wrap(uint64(x)+uint64(y), bits), nil
+		case '-':
+			return wrap(uint64(x)-uint64(y), bits), nil
+		case '*':
+			return wrap(uint64(x)*uint64(y), bits), nil
+		case '/':
+			if y == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			if y == -1 {
+				// the only division that overflows: the minimum divided by -1
+				return wrap(-uint64(x), bits), nil
+			}
+			return wrap(uint64(x/y), bits), nil
+		case '%':
+			if y == 0 {
+				return 0, fmt.Errorf("modulo by zero")
+			}
+			return x % y, nil // never overflows, the minimum % -1 is 0
+		case '^':
+			if y < 0 {
+				return negativePow(x, y)
+			}
+			return wrap(wrappingPow(uint64(x), uint64(y)), bits), nil
+		}
+		return 0, fmt.Errorf("unsupported binary operator: %q", e.op)
+	}
+	return 0, fmt.Errorf("cannot evaluate %v as a fixed-width integer", e)
+}
@@ -0,0 +1,60 @@
+package expr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvalWrap(t *testing.T) {
+	tests := []struct {
+		input string
+		bits  int
+		want  int64
+	}{
+		{"2147483648 + 2147483648", 32, 0},
+		{"1073741824 + 1073741824", 32, math.MinInt32},
+		{"2147483647 + 1", 32, math.MinInt32},
+		{"2147483647 + 1", 64, 2147483648},
+		{"-2147483648 - 1", 32, math.MaxInt32},
+		{"65536 * 65536", 32, 0},
+		{"65535 * 65537", 32, -1},
+		{"4611686018427387904 * 2", 64, math.MinInt64},
+		{"255 + 1", 8, 0},
+		{"127 + 1", 8, -128},
+		{"-128 / -1", 8, -128},
+		{"-7 / 2", 32, -3},
+		{"300", 8, 44},
+		{"2 ^ 31 + 2 ^ 31", 32, 0},
+		{"2 ^ 31", 32, math.MinInt32},
+		{"3 ^ 40", 64, -6289078614652622815}, // 3^40 - 2^64
+		{"(-3) ^ 3", 32, -27},
+		{"2 ^ -1", 32, 0},
+		{"(-1) ^ -3", 32, -1},
+	}
+	for _, test := range tests {
+		got, err := EvalWrap(mustParse(t, test.input), test.bits)
+		if err != nil {
+			t.Fatalf("EvalWrap(%q, %d) failed: %v", test.input, test.bits, err)
+		}
+		if got != test.want {
+			t.Errorf("EvalWrap(%q, %d) = %d, want %d", test.input, test.bits, got, test.want)
+		}
+	}
+}
+
+func TestEvalWrapErrors(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		bits  int
+	}{
+		{"1 / 0", 32},
+		{"0 ^ -1", 32},
+		{"1.5", 32},
+		{"1", 0},
+		{"1", 65},
+	} {
+		if _, err := EvalWrap(mustParse(t, test.input), test.bits); err == nil {
+			t.Errorf("EvalWrap(%q, %d) succeeded, want error", test.input, test.bits)
+		}
+	}
+}
@@ -2,41 +2,206 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"log"
+	"math"
+	"math/big"
 	"os"
+	"path/filepath"
+	"runtime"
 	"runtime/pprof"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
 
+	"github.com/jerberlin/calcast/expr"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
 )
 
 func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run executes the calculator with the given command line arguments,
+// reading manual input from stdin and writing the result to stdout.
+// Usage and diagnostic messages go to stderr.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) (err error) {
 	defaultPath := "./testdata/1k.txt"
+	var filePaths fileList
+
+	flags := flag.NewFlagSet("calcast", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Var(&filePaths, "f", "Path to the file containing the math expression; repeat it to combine several files. (default "+defaultPath+")")
+	combine := flags.String("combine", "+", "Operator combining the expressions of several files, one of + - * / % ^.")
+	evalFlag := flags.Bool("eval", false, "Use EvalParse function for in-place evaluation.")
+	profile := flags.Bool("profile", false, "Enable heap profiling.") // for mem analysis and optimisation purposes
+	manualInput := flags.Bool("i", false, "Read input manually from stdin instead of from a file.")
+	envVar := flags.String("env-var", "", "Name of an environment variable containing the math expression.")
+	outputPath := flags.String("o", "", "Write the result to this file instead of stdout.")
+	compare := flags.Bool("compare", false, "Compare the float64 result with the exact rational result.")
+	traceEval := flags.Bool("trace-eval", false, "Log every operation and its result to stderr while evaluating.")
+	profileAlloc := flags.Bool("profile-alloc", false, "Print the memory allocated while parsing and evaluating to stderr.")
+	dryRun := flags.Bool("dry-run", false, "Parse the expression and print its structure without evaluating it.")
+	assert := flags.String("assert", "", "Fail unless the result is within -tol of this value.")
+	tol := flags.Float64("tol", 1e-9, "Tolerance of -assert.")
+	quiet := flags.Bool("quiet", false, "Print only the bare result, for use in scripts.")
+	benchmark := flags.Int("benchmark", 0, "Parse and evaluate each file given with -f, or else each bundled test file, this many times and print the average timings.")
+	noEval := flags.Bool("no-eval", false, "Print the parsed expression in the notation given by -format instead of evaluating it.")
+	format := flags.String("format", "infix", "Notation printed by -no-eval, infix or rpn.")
+	warnPrecision := flags.Bool("warn-precision", false, "Warn on stderr when a compensated summation disagrees with the result.")
+	strict := flags.Bool("strict", false, "Fail on any overflow to Inf or NaN, and on every evaluation error with -eval.")
+	dir := flags.String("dir", "", "Evaluate every .txt file of this directory and combine the results with -combine.")
+	resultTemplate := flags.String("result-template", "", "Go template of the output line, e.g. '{{.Result}} m'; see the README for the fields.")
+	maxOps := flags.Int("max-ops", 0, "Refuse to evaluate expressions with more than this many operations; 0 means no limit.")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	var expected float64
+	if *assert != "" {
+		if expected, err = strconv.ParseFloat(*assert, 64); err != nil {
+			return fmt.Errorf("invalid -assert value %q: %v", *assert, err)
+		}
+	}
+	var tmpl *template.Template
+	if *resultTemplate != "" {
+		if tmpl, err = template.New("result").Parse(*resultTemplate); err != nil {
+			return fmt.Errorf("invalid -result-template: %v", err)
+		}
+	}
+	// EvalParse leaves no operations to trace and no sum to recompute
+	if *evalFlag && *traceEval {
+		return fmt.Errorf("-trace-eval cannot be combined with -eval")
+	}
+	if *evalFlag && *warnPrecision {
+		return fmt.Errorf("-warn-precision cannot be combined with -eval")
+	}
+	// the operations are counted in the parsed tree before evaluating it, which rules out
+	// EvalParse; the trace of -trace-eval comes with its own evaluator
+	if *maxOps > 0 && (*evalFlag || *traceEval) {
+		return fmt.Errorf("-max-ops cannot be combined with -eval or -trace-eval")
+	}
 
-	filePath := flag.String("f", defaultPath, "Path to the file containing the math expression.")
-	evalFlag := flag.Bool("eval", false, "Use EvalParse function for in-place evaluation.")
-	profile := flag.Bool("profile", false, "Enable heap profiling.") // for mem analysis and optimisation purposes
-	manualInput := flag.Bool("i", false, "Read input manually from stdin instead of from a file.")
+	// the result goes to the output file only once everything succeeded,
+	// so that a failure never leaves a half-written file behind;
+	// the report of -dir is complete even when some files failed, so it is written all the same
+	out := stdout
+	report := false
+	if *outputPath != "" {
+		var buf bytes.Buffer
+		out = &buf
+		defer func() {
+			if err == nil || report && buf.Len() > 0 {
+				if werr := writeFileAtomic(*outputPath, buf.Bytes()); err == nil {
+					err = werr
+				}
+			}
+		}()
+	}
 
-	flag.Parse()
+	if *benchmark > 0 {
+		paths := filePaths
+		if len(paths) == 0 {
+			for _, file := range benchmarkFiles {
+				paths = append(paths, filepath.Join("testdata", file))
+			}
+		}
+		return runBenchmark(out, paths, *benchmark, *evalFlag)
+	}
+
+	if *dir != "" {
+		if len(*combine) != 1 || !expr.IsBinaryOperator(rune((*combine)[0])) {
+			return fmt.Errorf("cannot combine results with %q, want one of + - * / %% ^", *combine)
+		}
+		report = true
+		return runDir(out, *dir, rune((*combine)[0]))
+	}
 
 	var reader io.Reader
-	var err error
 
-	// Input is optionally from stdin or from a file
+	// Input is optionally from stdin, from an environment variable or from a file
 	if *manualInput {
-		fmt.Println("Enter your math expression (CTRL+D to submit):")
-		reader = bufio.NewReader(os.Stdin)
+		if !*quiet {
+			fmt.Fprintln(stdout, "Enter your math expression (CTRL+D to submit):")
+		}
+		reader = bufio.NewReader(stdin)
+	} else if *envVar != "" {
+		value := os.Getenv(*envVar)
+		if strings.TrimSpace(value) == "" {
+			return fmt.Errorf("environment variable %s is not set or empty", *envVar)
+		}
+		reader = strings.NewReader(value)
 	} else {
-		file, err := os.Open(*filePath)
+		if len(filePaths) == 0 {
+			filePaths = fileList{defaultPath}
+		}
+		if len(filePaths) > 1 && (len(*combine) != 1 || !expr.IsBinaryOperator(rune((*combine)[0]))) {
+			return fmt.Errorf("cannot combine files with %q, want one of + - * / %% ^", *combine)
+		}
+
+		// several files are read as one expression: (file1) + (file2) + ...
+		var readers []io.Reader
+		for i, path := range filePaths {
+			file, err := os.Open(path)
+			if err != nil {
+				return fmt.Errorf("could not open file %s: %v", path, err)
+			}
+			defer file.Close()
+			if len(filePaths) == 1 {
+				readers = append(readers, file)
+				break
+			}
+			if i > 0 {
+				readers = append(readers, strings.NewReader(" "+*combine+" "))
+			}
+			readers = append(readers, strings.NewReader("("), file, strings.NewReader(")"))
+		}
+		reader = io.MultiReader(readers...)
+	}
+
+	// a dry run only reports the structure of the expression, it never evaluates
+	if *dryRun {
+		exp, stats, err := expr.ParseWithStats(reader)
+		if err != nil {
+			return fmt.Errorf("could not parse expression: %v", err)
+		}
+		printStats(out, exp, stats)
+		return nil
+	}
+
+	// the expression is only reformatted, never evaluated
+	if *noEval {
+		exp, err := expr.Parse(reader)
+		if err != nil {
+			return fmt.Errorf("could not parse expression: %v", err)
+		}
+		switch *format {
+		case "infix":
+			fmt.Fprintln(out, expr.Canonical(exp))
+		case "rpn":
+			fmt.Fprintln(out, expr.RPN(exp))
+		default:
+			return fmt.Errorf("unknown format %q, want infix or rpn", *format)
+		}
+		return nil
+	}
+
+	// the comparison needs the whole tree, so it never uses EvalParse
+	if *compare {
+		exp, err := expr.Parse(reader)
 		if err != nil {
-			log.Fatalf("Could not open file %s: %v", *filePath, err)
+			return fmt.Errorf("could not parse expression: %v", err)
 		}
-		defer file.Close()
-		reader = file
+		return printComparison(out, exp)
 	}
 
 	// ** CPU Profiling **
@@ -50,19 +215,48 @@ func main() {
 		}
 		f, err := os.Create(fileName)
 		if err != nil {
-			log.Fatal("could not create heap profile:", err)
+			return fmt.Errorf("could not create heap profile: %v", err)
 		}
 		defer f.Close()
 		if err := pprof.StartCPUProfile(f); err != nil {
-			log.Fatal("could not start CPU profile: ", err)
+			return fmt.Errorf("could not start CPU profile: %v", err)
 		}
 		defer pprof.StopCPUProfile()
 	}
 
-	exp, err := parseInput(reader, *evalFlag)
+	// ** Allocation Profiling **
+	// Count the allocations of each phase, without the profile files written in between.
+	// The counters are only read for -profile-alloc, as reading them stops the world.
+	snapshot := func() allocs {
+		if !*profileAlloc {
+			return allocs{}
+		}
+		return readAllocs()
+	}
+	startParse := snapshot()
+	parseStart := time.Now()
+	// with -eval, the value is all there is to evaluate, and the echoed expression is kept apart
+	var exp, shown expr.Expr
+	if *evalFlag {
+		var value float64
+		var opts []expr.Option
+		if *strict {
+			opts = append(opts, expr.WithStrictEval(), expr.WithFiniteEval())
+		}
+		shown, value, err = expr.EvalParseKeep(reader, opts...)
+		exp = expr.Num(value)
+		if err == nil && expr.KindOf(shown) == expr.NumberKind {
+			shown = nil // too long to echo, or nothing but a number anyway
+		}
+	} else {
+		exp, err = expr.Parse(reader)
+		shown = exp
+	}
 	if err != nil {
-		log.Fatalf("Could not parse expression: %v", err)
+		return fmt.Errorf("could not parse expression: %v", err)
 	}
+	parseAllocs := snapshot().since(startParse)
+	parseTime := time.Since(parseStart)
 
 	// ** Mem Profiling **
 	// Write heap profile after parsing
@@ -75,16 +269,40 @@ func main() {
 		}
 		f, err := os.Create(fileName)
 		if err != nil {
-			log.Fatal("could not create heap profile:", err)
+			return fmt.Errorf("could not create heap profile: %v", err)
 		}
 		defer f.Close()
 		pprof.WriteHeapProfile(f)
 	}
 
-	res, err := exp.Eval()
+	var res float64
+	startEval := snapshot()
+	evalStart := time.Now()
+	if *maxOps > 0 {
+		res, err = expr.EvalLimited(exp, *maxOps)
+		if err == nil && *strict {
+			// the count is within the limit, so a second pass with the checks of EvalFinite is cheap
+			res, err = expr.EvalFinite(exp)
+		}
+	} else if *traceEval {
+		res, err = evalTraced(exp, stderr)
+		if err == nil && *strict {
+			// the trace shows every operation, the checks of EvalFinite need a second pass
+			_, err = expr.EvalFinite(exp)
+		}
+	} else if *strict {
+		res, err = expr.EvalFinite(exp)
+	} else {
+		res, err = exp.Eval()
+	}
+	if err == nil && *strict && (math.IsInf(res, 0) || math.IsNaN(res)) {
+		err = fmt.Errorf("result %g is not finite", res)
+	}
 	if err != nil {
-		log.Fatalf("Failed evaluation: %v", err)
+		return fmt.Errorf("failed evaluation: %v", err)
 	}
+	evalAllocs := snapshot().since(startEval)
+	evalTime := time.Since(evalStart)
 
 	// ** Mem Profiling **
 	// Write heap profile after evaluation
@@ -97,30 +315,305 @@ func main() {
 		}
 		f, err := os.Create(fileName)
 		if err != nil {
-			log.Fatal("could not create heap profile:", err)
+			return fmt.Errorf("could not create heap profile: %v", err)
 		}
 		defer f.Close()
 		pprof.WriteHeapProfile(f)
 	}
 
-	printResult(exp, res)
-}
+	if *warnPrecision {
+		if err := checkPrecision(stderr, exp, res); err != nil {
+			return err
+		}
+	}
 
-func parseInput(reader io.Reader, useEval bool) (Expr, error) {
-	if useEval {
-		return EvalParse(reader)
-	} else {
-		return Parse(reader)
+	if *profileAlloc {
+		printAllocs(stderr, "parse", parseAllocs)
+		printAllocs(stderr, "eval", evalAllocs)
 	}
+
+	if *assert != "" && !expr.ApproxEqual(res, expected, *tol) {
+		return fmt.Errorf("assertion failed: result %s differs from %s by %g, more than %g",
+			expr.FormatCanonical(res), expr.FormatCanonical(expected), math.Abs(res-expected), *tol)
+	}
+
+	if tmpl != nil {
+		fields := resultFields{
+			Result:        res,
+			Formatted:     expr.FormatResult(res),
+			Canonical:     expr.FormatCanonical(res),
+			DurationParse: parseTime,
+			DurationEval:  evalTime,
+		}
+		if shown != nil && shown.Len() <= expr.MaxEchoLen {
+			fields.Expr = shown.String()
+		}
+		if err := tmpl.Execute(out, fields); err != nil {
+			return fmt.Errorf("could not execute -result-template: %v", err)
+		}
+		fmt.Fprintln(out)
+		return nil
+	}
+	if *quiet {
+		fmt.Fprintln(out, expr.FormatCanonical(res))
+		return nil
+	}
+	if shown == nil {
+		fmt.Fprintf(out, "Eval() = %s\n", expr.FormatResult(res))
+		return nil
+	}
+	expr.FprintResult(out, shown, res)
+	return nil
 }
 
-func printResult(exp Expr, res float64) {
+// resultFields are the fields available to the template of -result-template.
+type resultFields struct {
+	Expr          string        // the expression, empty if it is longer than expr.MaxEchoLen
+	Result        float64       // the result
+	Formatted     string        // the result as printed by default, with thousands separators
+	Canonical     string        // the result as printed by -quiet
+	DurationParse time.Duration // time spent parsing, including the evaluation with -eval
+	DurationEval  time.Duration // time spent evaluating
+}
+
+// allocs is a snapshot of the allocation counters of the runtime.
+type allocs struct {
+	bytes   uint64 // cumulative bytes allocated
+	mallocs uint64 // cumulative count of allocated objects
+}
+
+func readAllocs() allocs {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return allocs{m.TotalAlloc, m.Mallocs}
+}
+
+// since returns the allocations made between the snapshot start and a.
+func (a allocs) since(start allocs) allocs {
+	return allocs{a.bytes - start.bytes, a.mallocs - start.mallocs}
+}
+
+func printAllocs(w io.Writer, phase string, a allocs) {
 	// we use a new (English) printer for outputting thousands comma
 	p := message.NewPrinter(language.English)
+	p.Fprintf(w, "Allocations during %s: %d bytes in %d objects\n", phase, a.bytes, a.mallocs)
+}
 
-	if exp.Len() <= 1000 {
-		p.Printf("Eval(%v) = %.2f\n", exp, res)
+// Benchmark files represent different sizes of arithmetic expressions.
+var benchmarkFiles = []string{"1k.txt", "10k.txt", "100k.txt", "1m.txt", "10m.txt"}
+
+// runBenchmark parses and evaluates each file n times and writes a table with the average
+// time and allocations per run. The files are read into memory first, so that reading
+// them from disk is not measured.
+func runBenchmark(w io.Writer, paths []string, n int, useEval bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "File\tParse\tEval\tBytes\tObjects\t")
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("could not read file %s: %v", path, err)
+		}
+
+		var parseTime, evalTime time.Duration
+		start := readAllocs()
+		for i := 0; i < n; i++ {
+			t := time.Now()
+			exp, err := parseInput(bytes.NewReader(content), useEval)
+			if err != nil {
+				return fmt.Errorf("could not parse expression from %s: %v", path, err)
+			}
+			parseTime += time.Since(t)
+
+			t = time.Now()
+			if _, err := exp.Eval(); err != nil {
+				return fmt.Errorf("failed evaluation of %s: %v", path, err)
+			}
+			evalTime += time.Since(t)
+		}
+		a := readAllocs().since(start)
+		fmt.Fprintf(tw, "%s\t%v\t%v\t%d\t%d\t\n", filepath.Base(path),
+			parseTime/time.Duration(n), evalTime/time.Duration(n), a.bytes/uint64(n), a.mallocs/uint64(n))
+	}
+	return tw.Flush()
+}
+
+// runDir evaluates every .txt file in dir and writes the result of each, in the order of
+// the file names, and the results combined with op. A file that cannot be read, parsed
+// or evaluated is reported and left out of the total; an error at the end tells about it.
+func runDir(w io.Writer, dir string, op rune) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no .txt files in %s", dir)
+	}
+
+	// the files are parsed one after the other, and evaluated as a batch
+	exprs := make([]expr.Expr, len(paths))
+	errs := make([]error, len(paths))
+	for i, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		if exprs[i], err = expr.Parse(bytes.NewReader(content)); err != nil {
+			errs[i] = fmt.Errorf("could not parse expression: %v", err)
+		}
+	}
+	var valid []expr.Expr
+	var indices []int
+	for i := range exprs {
+		if errs[i] == nil {
+			valid = append(valid, exprs[i])
+			indices = append(indices, i)
+		}
+	}
+	results := make([]float64, len(paths))
+	values, evalErrs := expr.EvalAll(context.Background(), valid)
+	for j, i := range indices {
+		results[i], errs[i] = values[j], evalErrs[j]
+	}
+
+	var total expr.Expr
+	failed := 0
+	for i, path := range paths {
+		if errs[i] != nil {
+			fmt.Fprintf(w, "%s: error: %v\n", filepath.Base(path), errs[i])
+			failed++
+			continue
+		}
+		fmt.Fprintf(w, "%s: %s\n", filepath.Base(path), expr.FormatResult(results[i]))
+		if total == nil {
+			total = expr.Num(results[i])
+		} else {
+			total = expr.MustBinary(op, total, expr.Num(results[i]))
+		}
+	}
+
+	if total != nil {
+		res, err := total.Eval()
+		if err != nil {
+			return fmt.Errorf("could not combine the results: %v", err)
+		}
+		fmt.Fprintf(w, "Total: %s\n", expr.FormatResult(res))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files in %s failed", failed, len(paths), dir)
+	}
+	return nil
+}
+
+// fileList is a flag that can be given several times.
+type fileList []string
+
+func (l *fileList) String() string { return strings.Join(*l, ", ") }
+func (l *fileList) Set(path string) error {
+	*l = append(*l, path)
+	return nil
+}
+
+func parseInput(reader io.Reader, useEval bool) (expr.Expr, error) {
+	if useEval {
+		return expr.EvalParse(reader)
 	} else {
-		p.Printf("Eval() = %.2f\n", res)
+		return expr.Parse(reader)
+	}
+}
+
+// maxTraceLines caps the trace of -trace-eval, a large file would flood the terminal otherwise.
+const maxTraceLines = 1000
+
+// evalTraced evaluates exp and logs each binary operation to w, up to maxTraceLines of them.
+func evalTraced(exp expr.Expr, w io.Writer) (float64, error) {
+	ops := 0
+	res, err := expr.EvalHooked(exp, func(op rune, x, y, result float64) {
+		if ops < maxTraceLines {
+			fmt.Fprintf(w, "%g %c %g = %g\n", x, op, y, result)
+		}
+		ops++
+	})
+	if ops > maxTraceLines {
+		fmt.Fprintf(w, "... %d more operations not shown\n", ops-maxTraceLines)
+	}
+	return res, err
+}
+
+// maxRelativeLoss is the relative difference between the result and its compensated
+// recomputation above which -warn-precision warns, a few thousand ULPs.
+const maxRelativeLoss = 1e-12
+
+// checkPrecision recomputes the top-level sum of exp with compensated summation
+// and writes a warning to w if res differs too much from it.
+func checkPrecision(w io.Writer, exp expr.Expr, res float64) error {
+	comp, err := expr.CompensatedSum(exp)
+	if err != nil {
+		return fmt.Errorf("failed compensated evaluation: %v", err)
+	}
+	if math.Abs(res-comp) > maxRelativeLoss*math.Abs(comp) {
+		fmt.Fprintf(w, "warning: the result may have lost precision, compensated summation gives %s\n", expr.FormatCanonical(comp))
+	}
+	return nil
+}
+
+// printComparison evaluates exp both in float64 and exactly, and shows how far apart they are.
+func printComparison(w io.Writer, exp expr.Expr) error {
+	res, err := exp.Eval()
+	if err != nil {
+		return fmt.Errorf("failed evaluation: %v", err)
+	}
+	exact, err := expr.EvalRat(exp)
+	if err != nil {
+		return fmt.Errorf("failed exact evaluation: %v", err)
+	}
+
+	fmt.Fprintf(w, "float64:  %s\n", expr.FormatCanonical(res))
+	fmt.Fprintf(w, "exact:    %s\n", new(big.Float).SetPrec(256).SetRat(exact).Text('g', 30))
+
+	// the result is a finite float after the exact evaluation succeeded
+	diff := new(big.Rat).SetFloat64(res)
+	diff.Sub(diff, exact).Abs(diff)
+	abs, _ := diff.Float64()
+	fmt.Fprintf(w, "absolute difference: %g\n", abs)
+	if exact.Sign() != 0 {
+		rel, _ := diff.Quo(diff, new(big.Rat).Abs(exact)).Float64()
+		fmt.Fprintf(w, "relative difference: %g\n", rel)
+	}
+	return nil
+}
+
+func printStats(w io.Writer, exp expr.Expr, stats expr.Stats) {
+	if exp.Len() <= expr.MaxEchoLen {
+		fmt.Fprintf(w, "Expression: %v\n", exp)
+	}
+	fmt.Fprintf(w, "Nodes: %d\n", stats.Nodes)
+	fmt.Fprintf(w, "Depth: %d\n", stats.Depth)
+	fmt.Fprintf(w, "Parenthesis depth: %d\n", stats.MaxParenDepth)
+
+	for _, op := range stats.Operators() {
+		fmt.Fprintf(w, "Operator %c: %d\n", op, stats.Ops[op])
+	}
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it to path,
+// so that readers see either the old content or the complete new one.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("could not create output file: %v", err)
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly after the rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("could not write output file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("could not write output file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("could not write output file: %v", err)
 	}
+	return nil
 }
@@ -1,15 +1,17 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
-)
 
-// Benchmark files represent different sizes of arithmetic expressions.
-var benchmarkFiles = []string{"1k.txt", "10k.txt", "100k.txt", "1m.txt", "10m.txt"}
+	"github.com/jerberlin/calcast/expr"
+)
 
 func TestParseAndEvalPerformance(t *testing.T) {
 	path := "./testdata/"
@@ -24,8 +26,7 @@ func TestParseAndEvalPerformance(t *testing.T) {
 
 			// Measure the time taken by Parse function
 			startParse := time.Now()
-			expr, err := Parse(f)
-			// expr, err := EvalParse(f)
+			e, err := expr.Parse(f)
 			durationParse := time.Since(startParse)
 
 			if err != nil {
@@ -34,7 +35,7 @@ func TestParseAndEvalPerformance(t *testing.T) {
 
 			// Measure the time taken by Eval function
 			startEval := time.Now()
-			_, err = expr.Eval()
+			_, err = e.Eval()
 			durationEval := time.Since(startEval)
 
 			if err != nil {
@@ -62,8 +63,7 @@ func TestEvalParseAndEvalPerformance(t *testing.T) {
 
 			// Measure the time taken by Parse function
 			startEvalParse := time.Now()
-			// expr, err := Parse(f)
-			expr, err := EvalParse(f)
+			e, err := expr.EvalParse(f)
 			durationEvalParse := time.Since(startEvalParse)
 
 			if err != nil {
@@ -72,7 +72,7 @@ func TestEvalParseAndEvalPerformance(t *testing.T) {
 
 			// Measure the time taken by Eval function
 			startEval := time.Now()
-			_, err = expr.Eval()
+			_, err = e.Eval()
 			durationEval := time.Since(startEval)
 
 			if err != nil {
@@ -85,3 +85,379 @@ func TestEvalParseAndEvalPerformance(t *testing.T) {
 		})
 	}
 }
+
+func TestRunDryRunDoesNotEvaluate(t *testing.T) {
+	var out bytes.Buffer
+	err := run([]string{"-i", "-dry-run"}, strings.NewReader("1 / 0"), &out, io.Discard)
+	if err != nil {
+		t.Fatalf("dry run of a division by zero failed: %v", err)
+	}
+	if strings.Contains(out.String(), "Eval") {
+		t.Errorf("dry run evaluated the expression:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Nodes: 3") || !strings.Contains(out.String(), "Operator /: 1") {
+		t.Errorf("dry run output is missing the stats:\n%s", out.String())
+	}
+
+	// without the dry run, the same input fails
+	if err := run([]string{"-i"}, strings.NewReader("1 / 0"), &out, io.Discard); err == nil {
+		t.Error("evaluation of a division by zero succeeded")
+	}
+}
+
+func TestRunFromEnvVar(t *testing.T) {
+	t.Setenv("CALC_EXPR", "(1 + 2) * 3")
+	var out bytes.Buffer
+	if err := run([]string{"-env-var", "CALC_EXPR"}, nil, &out, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if want := "= 9.00\n"; !strings.HasSuffix(out.String(), want) {
+		t.Errorf("output %q does not end in %q", out.String(), want)
+	}
+
+	t.Setenv("CALC_EMPTY", " ")
+	for _, name := range []string{"CALC_EMPTY", "CALC_UNSET_FOR_SURE"} {
+		if err := run([]string{"-env-var", name}, nil, &out, io.Discard); err == nil {
+			t.Errorf("run with empty variable %s succeeded, want error", name)
+		}
+	}
+}
+
+func TestRunOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "result.txt")
+
+	var out bytes.Buffer
+	if err := run([]string{"-f", "./testdata/1k.txt", "-o", path}, nil, &out, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("run wrote %q to stdout, want everything in the file", out.String())
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("could not read output file: %v", err)
+	}
+	if !strings.HasPrefix(string(got), "Eval(") || !strings.HasSuffix(string(got), "= 248,253,190,541.04\n") {
+		t.Errorf("output file is incomplete: %q", got)
+	}
+
+	// a failing run leaves the previous result alone and no temporary files behind
+	if err := run([]string{"-i", "-o", path}, strings.NewReader("1 / 0"), &out, io.Discard); err == nil {
+		t.Fatal("run of a division by zero succeeded")
+	}
+	if again, _ := os.ReadFile(path); !bytes.Equal(again, got) {
+		t.Errorf("failed run changed the output file to %q", again)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("output directory contains %d files, want only the result", len(entries))
+	}
+}
+
+func TestRunCompare(t *testing.T) {
+	var out bytes.Buffer
+	// 1 gets lost when added to 1e16 in float64
+	if err := run([]string{"-i", "-compare"}, strings.NewReader("1e16 + 1 - 1e16"), &out, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	want := "float64:  0\n" +
+		"exact:    1\n" +
+		"absolute difference: 1\n" +
+		"relative difference: 1\n"
+	if !strings.HasSuffix(out.String(), want) {
+		t.Errorf("comparison output is\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestRunTraceEval(t *testing.T) {
+	var out, trace bytes.Buffer
+	if err := run([]string{"-i", "-trace-eval"}, strings.NewReader("1 + 2 * 3 - 4"), &out, &trace); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	want := "2 * 3 = 6\n" +
+		"1 + 6 = 7\n" +
+		"7 - 4 = 3\n"
+	if trace.String() != want {
+		t.Errorf("trace is\n%s\nwant\n%s", trace.String(), want)
+	}
+	if !strings.HasSuffix(out.String(), "= 3.00\n") {
+		t.Errorf("result missing from output %q", out.String())
+	}
+
+	trace.Reset()
+	if err := run([]string{"-f", "./testdata/10k.txt", "-trace-eval"}, nil, &out, &trace); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(trace.String(), "\n"), "\n")
+	if len(lines) != maxTraceLines+1 || !strings.HasSuffix(lines[maxTraceLines], "more operations not shown") {
+		t.Errorf("trace of a large input has %d lines, want it capped at %d", len(lines), maxTraceLines)
+	}
+
+	if err := run([]string{"-i", "-eval", "-trace-eval"}, strings.NewReader("1 + 2"), &out, &trace); err == nil {
+		t.Error("run with -eval and -trace-eval succeeded, want error")
+	}
+}
+
+func TestRunCombinesFiles(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")
+	os.WriteFile(a, []byte("1 + 2"), 0o644)
+	os.WriteFile(b, []byte("3 * 4\n"), 0o644)
+
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-f", a, "-f", b}, "= 15.00\n"},
+		{[]string{"-f", a, "-f", b, "-combine", "*"}, "= 36.00\n"},
+		{[]string{"-f", b, "-f", a, "-combine", "-"}, "= 9.00\n"},
+		{[]string{"-f", a}, "= 3.00\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		if err := run(test.args, nil, &out, io.Discard); err != nil {
+			t.Fatalf("run(%q) failed: %v", test.args, err)
+		}
+		if !strings.HasSuffix(out.String(), test.want) {
+			t.Errorf("run(%q) printed %q, want result %q", test.args, out.String(), test.want)
+		}
+	}
+
+	if err := run([]string{"-f", a, "-f", b, "-combine", "&"}, nil, io.Discard, io.Discard); err == nil {
+		t.Error("combining with an unknown operator succeeded")
+	}
+}
+
+func TestRunProfileAlloc(t *testing.T) {
+	var summary bytes.Buffer
+	if err := run([]string{"-f", "./testdata/100k.txt", "-profile-alloc"}, nil, io.Discard, &summary); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(summary.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("allocation summary is %q, want one line per phase", summary.String())
+	}
+	for i, phase := range []string{"parse", "eval"} {
+		var bytesAlloc, objects string
+		_, err := fmt.Sscanf(lines[i], "Allocations during "+phase+": %s bytes in %s objects", &bytesAlloc, &objects)
+		if err != nil {
+			t.Fatalf("could not read %q: %v", lines[i], err)
+		}
+		// parsing 100k of input cannot be done without allocating
+		if phase == "parse" && (bytesAlloc == "0" || objects == "0") {
+			t.Errorf("%s allocated nothing: %q", phase, lines[i])
+		}
+	}
+}
+
+func TestRunMaxOps(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"-i", "-max-ops", "2"}, strings.NewReader("1 + 2 * 3"), &out, io.Discard); err != nil {
+		t.Fatalf("run within the limit failed: %v", err)
+	}
+	huge := strings.Repeat("1 + ", 100000) + "1"
+	err := run([]string{"-i", "-max-ops", "1000"}, strings.NewReader(huge), &out, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "limit of 1000 operations") {
+		t.Errorf("run of %d additions with -max-ops 1000 returned %v, want limit error", 100000, err)
+	}
+}
+
+func TestRunAssert(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"-i", "-assert", "0.3"}, strings.NewReader("0.1 + 0.2"), &out, io.Discard); err != nil {
+		t.Errorf("passing assertion failed: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "= 0.30\n") {
+		t.Errorf("passing assertion did not print the result: %q", out.String())
+	}
+
+	err := run([]string{"-i", "-assert", "3", "-tol", "0.5"}, strings.NewReader("7 / 2 + 0.25"), &out, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "differs from 3 by 0.75") {
+		t.Errorf("failing assertion returned %v, want the discrepancy", err)
+	}
+	if err := run([]string{"-i", "-assert", "three"}, strings.NewReader("3"), &out, io.Discard); err == nil {
+		t.Error("assertion with an invalid value succeeded")
+	}
+}
+
+func TestRunQuiet(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"-i", "-quiet"}, strings.NewReader("1234 * 10 + 0.5"), &out, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if got, want := out.String(), "12340.5\n"; got != want {
+		t.Errorf("quiet output = %q, want %q", got, want)
+	}
+}
+
+func TestRunBenchmark(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"-benchmark", "2", "-f", "./testdata/1k.txt"}, nil, &out, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "Parse") || !strings.Contains(lines[1], "1k.txt") {
+		t.Errorf("benchmark output is not a table of one file:\n%s", out.String())
+	}
+
+	// the table goes to the file of -o like any other output
+	path := filepath.Join(t.TempDir(), "bench.txt")
+	out.Reset()
+	if err := run([]string{"-benchmark", "1", "-f", "./testdata/1k.txt", "-o", path}, nil, &out, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if got, err := os.ReadFile(path); err != nil || out.Len() != 0 || !strings.Contains(string(got), "1k.txt") {
+		t.Errorf("benchmark wrote %q to stdout and %q, %v to the output file, want the table in the file", out.String(), got, err)
+	}
+}
+
+func TestRunNoEval(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"-i", "-no-eval", "-format=rpn"}, strings.NewReader("1+2*3"), &out, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "\n1 2 3 * +\n") {
+		t.Errorf("output = %q, want the RPN form", out.String())
+	}
+
+	// nothing is evaluated, so a division by zero is fine
+	out.Reset()
+	if err := run([]string{"-i", "-no-eval"}, strings.NewReader("(1)/(0)"), &out, io.Discard); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if !strings.HasSuffix(out.String(), "\n1 / 0\n") {
+		t.Errorf("output = %q, want the canonical form", out.String())
+	}
+	if err := run([]string{"-i", "-no-eval", "-format=latex"}, strings.NewReader("1"), &out, io.Discard); err == nil {
+		t.Error("run with an unknown format succeeded")
+	}
+}
+
+func TestRunWarnPrecision(t *testing.T) {
+	var out, warnings bytes.Buffer
+	input := "1e16" + strings.Repeat(" + 1", 100) + " - 1e16"
+	if err := run([]string{"-i", "-warn-precision"}, strings.NewReader(input), &out, &warnings); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if !strings.Contains(warnings.String(), "compensated summation gives 100") {
+		t.Errorf("no precision warning for an ill-conditioned sum, stderr: %q", warnings.String())
+	}
+
+	warnings.Reset()
+	if err := run([]string{"-i", "-warn-precision"}, strings.NewReader("1 + 2.5 - 3 * 4"), &out, &warnings); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if warnings.Len() != 0 {
+		t.Errorf("precision warning for a well-conditioned sum: %q", warnings.String())
+	}
+
+	if err := run([]string{"-i", "-eval", "-warn-precision"}, strings.NewReader(input), &out, &warnings); err == nil {
+		t.Error("run with -eval and -warn-precision succeeded, want error")
+	}
+}
+
+func TestRunStrict(t *testing.T) {
+	var out bytes.Buffer
+	for _, input := range []string{"1e308 * 10", "1 / (1e308 * 10)", "1 / exp(1000)"} {
+		if err := run([]string{"-i"}, strings.NewReader(input), &out, io.Discard); err != nil {
+			t.Errorf("default run of %q failed: %v", input, err)
+		}
+		if err := run([]string{"-i", "-strict"}, strings.NewReader(input), &out, io.Discard); err == nil {
+			t.Errorf("strict run of %q succeeded, want error", input)
+		}
+		if err := run([]string{"-i", "-strict", "-trace-eval"}, strings.NewReader(input), &out, io.Discard); err == nil {
+			t.Errorf("strict traced run of %q succeeded, want error", input)
+		}
+	}
+	// both limits apply together
+	if err := run([]string{"-i", "-strict", "-max-ops", "10"}, strings.NewReader("1 + 2"), &out, io.Discard); err != nil {
+		t.Errorf("run with -strict and -max-ops failed: %v", err)
+	}
+	if err := run([]string{"-i", "-strict", "-max-ops", "10"}, strings.NewReader("1 / (1e308 * 10)"), &out, io.Discard); err == nil {
+		t.Error("run with -strict and -max-ops of an overflow succeeded, want error")
+	}
+	if err := run([]string{"-i", "-strict", "-max-ops", "1"}, strings.NewReader("1 + 2 + 3"), &out, io.Discard); err == nil {
+		t.Error("run with -strict over the -max-ops limit succeeded, want error")
+	}
+
+	// in-place evaluation ignores a division by zero unless strict
+	if err := run([]string{"-i", "-eval"}, strings.NewReader("1 + 1 / 0"), &out, io.Discard); err != nil {
+		t.Errorf("default run with -eval failed: %v", err)
+	}
+	if err := run([]string{"-i", "-eval", "-strict"}, strings.NewReader("1 + 1 / 0"), &out, io.Discard); err == nil {
+		t.Error("strict run with -eval of a division by zero succeeded, want error")
+	}
+	// and checks every partial result too
+	if err := run([]string{"-i", "-eval", "-strict"}, strings.NewReader("1 / (1e308 * 10)"), &out, io.Discard); err == nil {
+		t.Error("strict run with -eval of an overflow succeeded, want error")
+	}
+}
+
+func TestRunDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.txt":    "1 + 2",
+		"b.txt":    "(4 * 2)",
+		"c.txt":    "1 +",
+		"d.txt":    "1 / 0",
+		"notes.md": "not an expression",
+		"z.txt":    "0.5",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	err := run([]string{"-dir", dir, "-combine", "*"}, nil, &out, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "2 of 5 files") {
+		t.Errorf("run returned %v, want an error about 2 of 5 files", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	want := []string{"a.txt: 3.00", "b.txt: 8.00", "c.txt: error: ", "d.txt: error: ", "z.txt: 0.50", "Total: 12.00"}
+	if len(lines) != len(want) {
+		t.Fatalf("output is\n%s\nwant %d lines", out.String(), len(want))
+	}
+	for i := range want {
+		if !strings.HasPrefix(lines[i], want[i]) {
+			t.Errorf("line %d = %q, want %q", i+1, lines[i], want[i])
+		}
+	}
+
+	// the report is written to the file of -o even though some files failed
+	path := filepath.Join(t.TempDir(), "report.txt")
+	if err := run([]string{"-dir", dir, "-combine", "*", "-o", path}, nil, io.Discard, io.Discard); err == nil {
+		t.Error("run with -o succeeded, want the error about the failed files")
+	}
+	if got, err := os.ReadFile(path); err != nil || string(got) != out.String() {
+		t.Errorf("output file holds %q, %v, want the report\n%s", got, err, out.String())
+	}
+}
+
+func TestRunResultTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"{{.Result}} units", "7000.5 units\n"},
+		{"{{.Expr}} is {{.Formatted}}", "2.00 * 3500.25 is 7,000.50\n"},
+		{"{{.Canonical}} in {{printf \"%T\" .DurationEval}}", "7000.5 in time.Duration\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		if err := run([]string{"-i", "-quiet", "-result-template", test.template}, strings.NewReader("2 * 3500.25"), &out, io.Discard); err != nil {
+			t.Fatalf("run with template %q failed: %v", test.template, err)
+		}
+		if got := out.String(); got != test.want {
+			t.Errorf("output of template %q = %q, want %q", test.template, got, test.want)
+		}
+	}
+
+	if err := run([]string{"-i", "-result-template", "{{.Result"}, strings.NewReader("1"), io.Discard, io.Discard); err == nil {
+		t.Error("run with a malformed template succeeded, want error")
+	}
+	if err := run([]string{"-i", "-result-template", "{{.Missing}}"}, strings.NewReader("1"), io.Discard, io.Discard); err == nil {
+		t.Error("run with an unknown template field succeeded, want error")
+	}
+}